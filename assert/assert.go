@@ -2,11 +2,33 @@ package assert
 
 import (
 	"fmt"
+	"sync/atomic"
 )
 
+var strict atomic.Bool
+
+// SetStrict makes failed assertions crash the process even where they would be recovered from,
+// which keeps their stack intact while developing golox. It applies to every goroutine
+func SetStrict(isStrict bool) {
+	strict.Store(isStrict)
+}
+
+func IsStrict() bool {
+	return strict.Load()
+}
+
+// the value assertions panic with when they fail, which lets the panic be told apart from others and recovered from
+type Failure struct {
+	Msg string
+}
+
+func (f *Failure) Error() string {
+	return "ASSERTION FAILED: " + f.Msg
+}
+
 func That(condition bool, msg any) {
 	if !condition {
-		panic(fmt.Sprintf("ASSERTION FAILED: %s", msg))
+		panic(&Failure{Msg: fmt.Sprint(msg)})
 	}
 
 	// return nil
@@ -14,7 +36,7 @@ func That(condition bool, msg any) {
 
 func Eq[T comparable](left, right T) {
 	if left != right {
-		panic(fmt.Sprintf("ASSERTION FAILED: '%v' == '%v'", left, right))
+		panic(&Failure{Msg: fmt.Sprintf("'%v' == '%v'", left, right)})
 	}
 
 	// return nil
@@ -22,7 +44,7 @@ func Eq[T comparable](left, right T) {
 
 func EqWithMessage[T comparable](left, right T, msg any) {
 	if left != right {
-		panic(fmt.Sprintf("ASSERTION FAILED: '%v' == '%v': %s", left, right, msg))
+		panic(&Failure{Msg: fmt.Sprintf("'%v' == '%v': %s", left, right, msg)})
 	}
 
 	// return nil
@@ -30,7 +52,7 @@ func EqWithMessage[T comparable](left, right T, msg any) {
 
 func NotEq[T comparable](left, right T) {
 	if left == right {
-		panic(fmt.Sprintf("ASSERTION FAILED: '%v' != '%v'", left, right))
+		panic(&Failure{Msg: fmt.Sprintf("'%v' != '%v'", left, right)})
 	}
 
 	// return nil
@@ -38,12 +60,12 @@ func NotEq[T comparable](left, right T) {
 
 func NotEqWithMessage[T comparable](left, right T, msg any) {
 	if left == right {
-		panic(fmt.Sprintf("ASSERTION FAILED: '%v' != '%v': %s", left, right, msg))
+		panic(&Failure{Msg: fmt.Sprintf("'%v' != '%v': %s", left, right, msg)})
 	}
 
 	// return nil
 }
 
 func Unreachable(msg any) {
-	panic(fmt.Sprintf("ASSERTION FAILED: code is unreachable: %s", msg))
+	panic(&Failure{Msg: fmt.Sprintf("code is unreachable: %s", msg)})
 }
@@ -0,0 +1,329 @@
+package ast
+
+import (
+	"fmt"
+	"github.com/Drumstickz64/golox/token"
+)
+
+func exprToJson(node Expr) any {
+	switch node := node.(type) {
+	case *BinaryExpr:
+		if node == nil {
+			return nil
+		}
+
+		return map[string]any{
+			"type":     "BinaryExpr",
+			"left":     exprToJson(node.Left),
+			"operator": tokenToJson(node.Operator),
+			"right":    exprToJson(node.Right),
+		}
+	case *LogicalExpr:
+		if node == nil {
+			return nil
+		}
+
+		return map[string]any{
+			"type":     "LogicalExpr",
+			"left":     exprToJson(node.Left),
+			"operator": tokenToJson(node.Operator),
+			"right":    exprToJson(node.Right),
+		}
+	case *GroupingExpr:
+		if node == nil {
+			return nil
+		}
+
+		return map[string]any{
+			"type":       "GroupingExpr",
+			"expression": exprToJson(node.Expression),
+		}
+	case *LiteralExpr:
+		if node == nil {
+			return nil
+		}
+
+		return map[string]any{
+			"type":  "LiteralExpr",
+			"value": node.Value,
+		}
+	case *UnaryExpr:
+		if node == nil {
+			return nil
+		}
+
+		return map[string]any{
+			"type":     "UnaryExpr",
+			"operator": tokenToJson(node.Operator),
+			"right":    exprToJson(node.Right),
+		}
+	case *CallExpr:
+		if node == nil {
+			return nil
+		}
+
+		return map[string]any{
+			"type":      "CallExpr",
+			"callee":    exprToJson(node.Callee),
+			"paren":     tokenToJson(node.Paren),
+			"arguments": sliceToJson(node.Arguments, func(item Expr) any { return exprToJson(item) }),
+		}
+	case *GetExpr:
+		if node == nil {
+			return nil
+		}
+
+		return map[string]any{
+			"type":   "GetExpr",
+			"object": exprToJson(node.Object),
+			"name":   tokenToJson(node.Name),
+		}
+	case *SetExpr:
+		if node == nil {
+			return nil
+		}
+
+		return map[string]any{
+			"type":   "SetExpr",
+			"object": exprToJson(node.Object),
+			"name":   tokenToJson(node.Name),
+			"value":  exprToJson(node.Value),
+		}
+	case *SuperExpr:
+		if node == nil {
+			return nil
+		}
+
+		return map[string]any{
+			"type":    "SuperExpr",
+			"keyword": tokenToJson(node.Keyword),
+			"method":  tokenToJson(node.Method),
+		}
+	case *ThisExpr:
+		if node == nil {
+			return nil
+		}
+
+		return map[string]any{
+			"type":    "ThisExpr",
+			"keyword": tokenToJson(node.Keyword),
+		}
+	case *VariableExpr:
+		if node == nil {
+			return nil
+		}
+
+		return map[string]any{
+			"type": "VariableExpr",
+			"name": tokenToJson(node.Name),
+		}
+	case *AssignmentExpr:
+		if node == nil {
+			return nil
+		}
+
+		return map[string]any{
+			"type":  "AssignmentExpr",
+			"name":  tokenToJson(node.Name),
+			"value": exprToJson(node.Value),
+		}
+	}
+
+	return nil
+}
+
+func exprFromJson(data any) (Expr, error) {
+	if data == nil {
+		return nil, nil
+	}
+
+	fields, kind, err := nodeFields(data)
+	if err != nil {
+		return nil, err
+	}
+
+	switch kind {
+	case "BinaryExpr":
+		left, err := requiredNode(exprFromJson(fields["left"]))
+		if err != nil {
+			return nil, fmt.Errorf("BinaryExpr.left: %w", err)
+		}
+
+		operator, err := operatorFromJson(fields["operator"], token.PLUS, token.MINUS, token.STAR, token.SLASH, token.GREATER, token.GREATER_EQUAL, token.LESS, token.LESS_EQUAL, token.EQUAL_EQUAL, token.BANG_EQUAL)
+		if err != nil {
+			return nil, fmt.Errorf("BinaryExpr.operator: %w", err)
+		}
+
+		right, err := requiredNode(exprFromJson(fields["right"]))
+		if err != nil {
+			return nil, fmt.Errorf("BinaryExpr.right: %w", err)
+		}
+
+		return &BinaryExpr{
+			Left:     left,
+			Operator: operator,
+			Right:    right,
+		}, nil
+	case "LogicalExpr":
+		left, err := requiredNode(exprFromJson(fields["left"]))
+		if err != nil {
+			return nil, fmt.Errorf("LogicalExpr.left: %w", err)
+		}
+
+		operator, err := operatorFromJson(fields["operator"], token.AND, token.OR)
+		if err != nil {
+			return nil, fmt.Errorf("LogicalExpr.operator: %w", err)
+		}
+
+		right, err := requiredNode(exprFromJson(fields["right"]))
+		if err != nil {
+			return nil, fmt.Errorf("LogicalExpr.right: %w", err)
+		}
+
+		return &LogicalExpr{
+			Left:     left,
+			Operator: operator,
+			Right:    right,
+		}, nil
+	case "GroupingExpr":
+		expression, err := requiredNode(exprFromJson(fields["expression"]))
+		if err != nil {
+			return nil, fmt.Errorf("GroupingExpr.expression: %w", err)
+		}
+
+		return &GroupingExpr{
+			Expression: expression,
+		}, nil
+	case "LiteralExpr":
+		value, err := literalFromJson(fields["value"])
+		if err != nil {
+			return nil, fmt.Errorf("LiteralExpr.value: %w", err)
+		}
+
+		return &LiteralExpr{
+			Value: value,
+		}, nil
+	case "UnaryExpr":
+		operator, err := operatorFromJson(fields["operator"], token.MINUS, token.BANG)
+		if err != nil {
+			return nil, fmt.Errorf("UnaryExpr.operator: %w", err)
+		}
+
+		right, err := requiredNode(exprFromJson(fields["right"]))
+		if err != nil {
+			return nil, fmt.Errorf("UnaryExpr.right: %w", err)
+		}
+
+		return &UnaryExpr{
+			Operator: operator,
+			Right:    right,
+		}, nil
+	case "CallExpr":
+		callee, err := requiredNode(exprFromJson(fields["callee"]))
+		if err != nil {
+			return nil, fmt.Errorf("CallExpr.callee: %w", err)
+		}
+
+		paren, err := tokenFromJson(fields["paren"])
+		if err != nil {
+			return nil, fmt.Errorf("CallExpr.paren: %w", err)
+		}
+
+		arguments, err := sliceFromJson(fields["arguments"], func(item any) (Expr, error) { return requiredNode(exprFromJson(item)) })
+		if err != nil {
+			return nil, fmt.Errorf("CallExpr.arguments: %w", err)
+		}
+
+		return &CallExpr{
+			Callee:    callee,
+			Paren:     paren,
+			Arguments: arguments,
+		}, nil
+	case "GetExpr":
+		object, err := requiredNode(exprFromJson(fields["object"]))
+		if err != nil {
+			return nil, fmt.Errorf("GetExpr.object: %w", err)
+		}
+
+		name, err := tokenFromJson(fields["name"])
+		if err != nil {
+			return nil, fmt.Errorf("GetExpr.name: %w", err)
+		}
+
+		return &GetExpr{
+			Object: object,
+			Name:   name,
+		}, nil
+	case "SetExpr":
+		object, err := requiredNode(exprFromJson(fields["object"]))
+		if err != nil {
+			return nil, fmt.Errorf("SetExpr.object: %w", err)
+		}
+
+		name, err := tokenFromJson(fields["name"])
+		if err != nil {
+			return nil, fmt.Errorf("SetExpr.name: %w", err)
+		}
+
+		value, err := requiredNode(exprFromJson(fields["value"]))
+		if err != nil {
+			return nil, fmt.Errorf("SetExpr.value: %w", err)
+		}
+
+		return &SetExpr{
+			Object: object,
+			Name:   name,
+			Value:  value,
+		}, nil
+	case "SuperExpr":
+		keyword, err := tokenFromJson(fields["keyword"])
+		if err != nil {
+			return nil, fmt.Errorf("SuperExpr.keyword: %w", err)
+		}
+
+		method, err := tokenFromJson(fields["method"])
+		if err != nil {
+			return nil, fmt.Errorf("SuperExpr.method: %w", err)
+		}
+
+		return &SuperExpr{
+			Keyword: keyword,
+			Method:  method,
+		}, nil
+	case "ThisExpr":
+		keyword, err := tokenFromJson(fields["keyword"])
+		if err != nil {
+			return nil, fmt.Errorf("ThisExpr.keyword: %w", err)
+		}
+
+		return &ThisExpr{
+			Keyword: keyword,
+		}, nil
+	case "VariableExpr":
+		name, err := tokenFromJson(fields["name"])
+		if err != nil {
+			return nil, fmt.Errorf("VariableExpr.name: %w", err)
+		}
+
+		return &VariableExpr{
+			Name: name,
+		}, nil
+	case "AssignmentExpr":
+		name, err := tokenFromJson(fields["name"])
+		if err != nil {
+			return nil, fmt.Errorf("AssignmentExpr.name: %w", err)
+		}
+
+		value, err := requiredNode(exprFromJson(fields["value"]))
+		if err != nil {
+			return nil, fmt.Errorf("AssignmentExpr.value: %w", err)
+		}
+
+		return &AssignmentExpr{
+			Name:  name,
+			Value: value,
+		}, nil
+	}
+
+	return nil, fmt.Errorf("unknown expr type '%s'", kind)
+}
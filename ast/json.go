@@ -0,0 +1,203 @@
+package ast
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"slices"
+
+	"github.com/Drumstickz64/golox/token"
+)
+
+// incremented whenever the JSON representation of the AST changes in an incompatible way
+const JsonVersion = 1
+
+type jsonProgram struct {
+	Version    int   `json:"version"`
+	Statements []any `json:"statements"`
+}
+
+// ProgramToJson converts statements into the versioned JSON representation of the AST.
+// Every node is an object with a "type" key holding its type name, and a key for each of its fields.
+// Tokens are objects with their kind, lexeme, literal, line and column
+func ProgramToJson(statements []Stmt) ([]byte, error) {
+	return json.MarshalIndent(jsonProgram{
+		Version:    JsonVersion,
+		Statements: sliceToJson(statements, stmtToJson),
+	}, "", "  ")
+}
+
+// ProgramFromJson is the inverse of ProgramToJson
+func ProgramFromJson(data []byte) ([]Stmt, error) {
+	program := jsonProgram{}
+	if err := json.Unmarshal(data, &program); err != nil {
+		return nil, err
+	}
+
+	if program.Version != JsonVersion {
+		return nil, fmt.Errorf("unsupported AST version %d, expected version %d", program.Version, JsonVersion)
+	}
+
+	statements := make([]Stmt, 0, len(program.Statements))
+	for i, data := range program.Statements {
+		statement, err := requiredNode(stmtFromJson(data))
+		if err != nil {
+			return nil, fmt.Errorf("statement %d: %w", i, err)
+		}
+
+		statements = append(statements, statement)
+	}
+
+	return statements, nil
+}
+
+func tokenToJson(tok token.Token) any {
+	return map[string]any{
+		"kind":    tok.Kind.String(),
+		"lexeme":  tok.Lexeme,
+		"literal": tok.Literal,
+		"line":    tok.Line,
+		"column":  tok.Column,
+	}
+}
+
+func tokenFromJson(data any) (token.Token, error) {
+	fields, ok := data.(map[string]any)
+	if !ok {
+		return token.Token{}, fmt.Errorf("expected a token object")
+	}
+
+	kindName, _ := fields["kind"].(string)
+	kind, ok := token.ParseKind(kindName)
+	if !ok {
+		return token.Token{}, fmt.Errorf("unknown token kind '%s'", kindName)
+	}
+
+	lexeme, ok := fields["lexeme"].(string)
+	if !ok {
+		return token.Token{}, fmt.Errorf("expected token lexeme to be a string")
+	}
+
+	literal, err := literalFromJson(fields["literal"])
+	if err != nil {
+		return token.Token{}, err
+	}
+
+	line, err := integerFromJson(fields["line"])
+	if err != nil {
+		return token.Token{}, fmt.Errorf("token line: %w", err)
+	}
+
+	column, err := integerFromJson(fields["column"])
+	if err != nil {
+		return token.Token{}, fmt.Errorf("token column: %w", err)
+	}
+
+	return token.Token{
+		Kind:    kind,
+		Lexeme:  lexeme,
+		Literal: literal,
+		Line:    line,
+		Column:  column,
+	}, nil
+}
+
+func literalFromJson(data any) (any, error) {
+	switch data.(type) {
+	case nil, float64, string, bool:
+		return data, nil
+	}
+
+	return nil, fmt.Errorf("literals must be null, numbers, strings or booleans")
+}
+
+func integerFromJson(data any) (int, error) {
+	number, ok := data.(float64)
+	if !ok || number != math.Trunc(number) {
+		return 0, fmt.Errorf("expected an integer")
+	}
+
+	return int(number), nil
+}
+
+func sliceToJson[T any](items []T, convert func(item T) any) []any {
+	result := make([]any, 0, len(items))
+	for _, item := range items {
+		result = append(result, convert(item))
+	}
+
+	return result
+}
+
+func sliceFromJson[T any](data any, convert func(item any) (T, error)) ([]T, error) {
+	items, ok := data.([]any)
+	if !ok {
+		return nil, fmt.Errorf("expected an array")
+	}
+
+	result := make([]T, 0, len(items))
+	for i, item := range items {
+		converted, err := convert(item)
+		if err != nil {
+			return nil, fmt.Errorf("item %d: %w", i, err)
+		}
+
+		result = append(result, converted)
+	}
+
+	return result, nil
+}
+
+// converts a decoded node into a specific kind of node
+func castNode[T any](node any, err error) (T, error) {
+	var zero T
+	if err != nil || node == nil {
+		return zero, err
+	}
+
+	result, ok := node.(T)
+	if !ok {
+		return zero, fmt.Errorf("expected a node of type %T but got %T", zero, node)
+	}
+
+	return result, nil
+}
+
+// fails when a node that has to be present is null
+func requiredNode[T comparable](node T, err error) (T, error) {
+	var zero T
+	if err == nil && node == zero {
+		return zero, fmt.Errorf("expected a node but got null")
+	}
+
+	return node, err
+}
+
+// decodes the token of an operator, which has to be one of kinds
+func operatorFromJson(data any, kinds ...token.Kind) (token.Token, error) {
+	tok, err := tokenFromJson(data)
+	if err != nil {
+		return token.Token{}, err
+	}
+
+	if !slices.Contains(kinds, tok.Kind) {
+		return token.Token{}, fmt.Errorf("'%s' isn't a valid operator here", tok.Kind)
+	}
+
+	return tok, nil
+}
+
+// returns the fields of a node object and the name of its type
+func nodeFields(data any) (map[string]any, string, error) {
+	fields, ok := data.(map[string]any)
+	if !ok {
+		return nil, "", fmt.Errorf("expected a node object")
+	}
+
+	kind, ok := fields["type"].(string)
+	if !ok {
+		return nil, "", fmt.Errorf("expected node to have a type")
+	}
+
+	return fields, kind, nil
+}
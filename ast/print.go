@@ -3,9 +3,18 @@ package ast
 import (
 	"fmt"
 	"slices"
+	"strings"
+
+	"github.com/Drumstickz64/golox/token"
 )
 
-type Printer struct{}
+type Printer struct {
+	// appends the line and column of the token to every node that has one
+	ShowPositions bool
+	// when set, used to append the resolved scope depth to every variable access.
+	// Returns false for globals
+	Depths func(expr Expr) (int, bool)
+}
 
 func NewPrinter() Printer {
 	return Printer{}
@@ -20,12 +29,36 @@ func (p Printer) Print(expr Expr) (string, error) {
 	return res.(string), err
 }
 
+// prints every statement on its own line, nested statements are indented
+func (p Printer) PrintProgram(statements []Stmt) (string, error) {
+	lines := []string{}
+	for _, statement := range statements {
+		line, err := p.PrintStmt(statement)
+		if err != nil {
+			return "", err
+		}
+
+		lines = append(lines, line)
+	}
+
+	return strings.Join(lines, "\n"), nil
+}
+
+func (p Printer) PrintStmt(stmt Stmt) (string, error) {
+	res, err := stmt.Accept(p)
+	if err != nil {
+		return "", err
+	}
+
+	return res.(string), err
+}
+
 func (p Printer) VisitBinaryExpr(expr *BinaryExpr) (any, error) {
-	return p.parenthesize(expr.Operator.Lexeme, expr.Left, expr.Right), nil
+	return p.parenthesize(p.tokenName(expr.Operator), expr.Left, expr.Right), nil
 }
 
 func (p Printer) VisitLogicalExpr(expr *LogicalExpr) (any, error) {
-	return p.parenthesize(expr.Operator.Lexeme, expr.Left, expr.Right), nil
+	return p.parenthesize(p.tokenName(expr.Operator), expr.Left, expr.Right), nil
 }
 
 func (p Printer) VisitGroupingExpr(expr *GroupingExpr) (any, error) {
@@ -37,46 +70,117 @@ func (p Printer) VisitLiteralExpr(expr *LiteralExpr) (any, error) {
 		return "nil", nil
 	}
 
+	if str, ok := expr.Value.(string); ok {
+		return fmt.Sprintf("%q", str), nil
+	}
+
 	return fmt.Sprint(expr.Value), nil
 }
 
 func (p Printer) VisitUnaryExpr(expr *UnaryExpr) (any, error) {
-	return p.parenthesize(expr.Operator.Lexeme, expr.Right), nil
+	return p.parenthesize(p.tokenName(expr.Operator), expr.Right), nil
 }
 
 func (p Printer) VisitCallExpr(expr *CallExpr) (any, error) {
 	args := slices.Concat([]Expr{expr.Callee}, expr.Arguments)
-	return p.parenthesize("<fn>", args...), nil
+	return p.parenthesize(p.positioned("call", expr.Paren), args...), nil
 }
 
 func (p Printer) VisitGetExpr(expr *GetExpr) (any, error) {
 	objectStr, _ := expr.Object.Accept(p)
-	return fmt.Sprintf("(. %s %s)", objectStr, expr.Name), nil
+	return fmt.Sprintf("(. %s %s)", objectStr, p.tokenName(expr.Name)), nil
 }
 
 func (p Printer) VisitSetExpr(expr *SetExpr) (any, error) {
 	objectStr, _ := expr.Object.Accept(p)
 	valueStr, _ := expr.Value.Accept(p)
-	return fmt.Sprintf("(.= %s %s %s)", objectStr, expr.Name, valueStr), nil
+	return fmt.Sprintf("(.= %s %s %s)", objectStr, p.tokenName(expr.Name), valueStr), nil
 }
 
 func (p Printer) VisitSuperExpr(expr *SuperExpr) (any, error) {
-	return fmt.Sprintf(". (super) (%s)", expr.Method), nil
+	return fmt.Sprintf("(. %s %s)", p.resolved(p.tokenName(expr.Keyword), expr), p.tokenName(expr.Method)), nil
 }
 
 func (p Printer) VisitThisExpr(expr *ThisExpr) (any, error) {
-	return "(this)", nil
+	return "(" + p.resolved(p.tokenName(expr.Keyword), expr) + ")", nil
 }
 
 func (p Printer) VisitVariableExpr(expr *VariableExpr) (any, error) {
-	return p.parenthesize("var " + expr.Name.Lexeme), nil
+	return p.parenthesize(p.resolved("var "+p.tokenName(expr.Name), expr)), nil
 }
 
 func (p Printer) VisitAssignmentExpr(expr *AssignmentExpr) (any, error) {
-	return p.parenthesize("=", &LiteralExpr{Value: expr.Name}, expr.Value), nil
+	return p.parenthesize(p.resolved("= "+p.tokenName(expr.Name), expr), expr.Value), nil
+}
+
+func (p Printer) VisitBlockStmt(stmt *BlockStmt) (any, error) {
+	return p.parenthesizeStmts("block", stmt.Statements...), nil
+}
+
+func (p Printer) VisitClassStmt(stmt *ClassStmt) (any, error) {
+	name := "class " + p.tokenName(stmt.Name)
+	if stmt.SuperClass != nil {
+		superClass, _ := stmt.SuperClass.Accept(p)
+		name += " < " + superClass.(string)
+	}
+
+	methods := make([]Stmt, 0, len(stmt.Methods))
+	for _, method := range stmt.Methods {
+		methods = append(methods, method)
+	}
+
+	return p.parenthesizeStmts(name, methods...), nil
+}
+
+func (p Printer) VisitExpressionStmt(stmt *ExpressionStmt) (any, error) {
+	return p.parenthesize(";", stmt.Expression), nil
+}
+
+func (p Printer) VisitWhileStmt(stmt *WhileStmt) (any, error) {
+	condition, _ := stmt.Condition.Accept(p)
+	return p.parenthesizeStmts("while "+condition.(string), stmt.Body), nil
+}
+
+func (p Printer) VisitIfStmt(stmt *IfStmt) (any, error) {
+	condition, _ := stmt.Condition.Accept(p)
+	if stmt.ElseBranch == nil {
+		return p.parenthesizeStmts("if "+condition.(string), stmt.ThenBranch), nil
+	}
+
+	return p.parenthesizeStmts("if-else "+condition.(string), stmt.ThenBranch, stmt.ElseBranch), nil
+}
+
+func (p Printer) VisitPrintStmt(stmt *PrintStmt) (any, error) {
+	return p.parenthesize("print", stmt.Expression), nil
 }
 
-func (p *Printer) parenthesize(name string, exps ...Expr) string {
+func (p Printer) VisitReturnStmt(stmt *ReturnStmt) (any, error) {
+	if stmt.Value == nil {
+		return p.parenthesize(p.tokenName(stmt.Keyword)), nil
+	}
+
+	return p.parenthesize(p.tokenName(stmt.Keyword), stmt.Value), nil
+}
+
+func (p Printer) VisitVarStmt(stmt *VarStmt) (any, error) {
+	if stmt.Initializer == nil {
+		return p.parenthesize("var " + p.tokenName(stmt.Name)), nil
+	}
+
+	return p.parenthesize("var "+p.tokenName(stmt.Name)+" =", stmt.Initializer), nil
+}
+
+func (p Printer) VisitFunctionStmt(stmt *FunctionStmt) (any, error) {
+	parameters := make([]string, 0, len(stmt.Parameters))
+	for _, parameter := range stmt.Parameters {
+		parameters = append(parameters, p.tokenName(parameter))
+	}
+
+	name := fmt.Sprintf("fun %s (%s)", p.tokenName(stmt.Name), strings.Join(parameters, " "))
+	return p.parenthesizeStmts(name, stmt.Body...), nil
+}
+
+func (p Printer) parenthesize(name string, exps ...Expr) string {
 	result := "(" + name
 
 	for _, expr := range exps {
@@ -88,3 +192,42 @@ func (p *Printer) parenthesize(name string, exps ...Expr) string {
 
 	return result
 }
+
+// like parenthesize, but puts every statement on its own indented line
+func (p Printer) parenthesizeStmts(name string, stmts ...Stmt) string {
+	result := "(" + name
+
+	for _, stmt := range stmts {
+		res, _ := stmt.Accept(p)
+		result += "\n  " + strings.ReplaceAll(res.(string), "\n", "\n  ")
+	}
+
+	result += ")"
+
+	return result
+}
+
+func (p Printer) tokenName(tok token.Token) string {
+	return p.positioned(tok.Lexeme, tok)
+}
+
+func (p Printer) positioned(name string, tok token.Token) string {
+	if !p.ShowPositions {
+		return name
+	}
+
+	return fmt.Sprintf("%s@%d:%d", name, tok.Line, tok.Column)
+}
+
+func (p Printer) resolved(name string, expr Expr) string {
+	if p.Depths == nil {
+		return name
+	}
+
+	depth, ok := p.Depths(expr)
+	if !ok {
+		return name + " [global]"
+	}
+
+	return fmt.Sprintf("%s [depth %d]", name, depth)
+}
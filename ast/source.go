@@ -0,0 +1,100 @@
+package ast
+
+import (
+	"github.com/Drumstickz64/golox/token"
+)
+
+// the first and last tokens of a statement in the source code
+type Span struct {
+	Start, End token.Token
+}
+
+// the clauses of a for loop as they were written in the source code,
+// before the parser desugared it into a while loop.
+// Initializer, Condition and Increment are nil when omitted
+type ForLoop struct {
+	Keyword     token.Token
+	Initializer Stmt
+	Condition   Expr
+	Increment   Expr
+	Body        Stmt
+	// the while loop it was desugared into, which is inside a block with the initializer when there is one
+	While *WhileStmt
+}
+
+// StmtToken returns the leftmost token of stmt that's kept in the tree, which tells roughly where it is
+// when its span isn't known. Returns false when stmt has no tokens, like an empty block
+func StmtToken(stmt Stmt) (token.Token, bool) {
+	switch stmt := stmt.(type) {
+	case *BlockStmt:
+		for _, statement := range stmt.Statements {
+			if tok, ok := StmtToken(statement); ok {
+				return tok, true
+			}
+		}
+	case *ClassStmt:
+		return stmt.Name, true
+	case *ExpressionStmt:
+		return ExprToken(stmt.Expression)
+	case *WhileStmt:
+		return ExprToken(stmt.Condition)
+	case *IfStmt:
+		return ExprToken(stmt.Condition)
+	case *PrintStmt:
+		return ExprToken(stmt.Expression)
+	case *ReturnStmt:
+		return stmt.Keyword, true
+	case *VarStmt:
+		return stmt.Name, true
+	case *FunctionStmt:
+		return stmt.Name, true
+	}
+
+	return token.Token{}, false
+}
+
+// ExprToken returns the leftmost token of expr that's kept in the tree, see StmtToken.
+// Returns false for literals, which have no tokens
+func ExprToken(expr Expr) (token.Token, bool) {
+	switch expr := expr.(type) {
+	case *BinaryExpr:
+		if tok, ok := ExprToken(expr.Left); ok {
+			return tok, true
+		}
+		return expr.Operator, true
+	case *LogicalExpr:
+		if tok, ok := ExprToken(expr.Left); ok {
+			return tok, true
+		}
+		return expr.Operator, true
+	case *GroupingExpr:
+		return ExprToken(expr.Expression)
+	case *UnaryExpr:
+		return expr.Operator, true
+	case *CallExpr:
+		if tok, ok := ExprToken(expr.Callee); ok {
+			return tok, true
+		}
+		return expr.Paren, true
+	case *GetExpr:
+		if tok, ok := ExprToken(expr.Object); ok {
+			return tok, true
+		}
+		return expr.Name, true
+	case *SetExpr:
+		if tok, ok := ExprToken(expr.Object); ok {
+			return tok, true
+		}
+		return expr.Name, true
+	case *SuperExpr:
+		return expr.Keyword, true
+	case *ThisExpr:
+		return expr.Keyword, true
+	case *VariableExpr:
+		return expr.Name, true
+	case *AssignmentExpr:
+		return expr.Name, true
+	}
+
+	return token.Token{}, false
+}
@@ -0,0 +1,256 @@
+package ast
+
+import (
+	"fmt"
+	"github.com/Drumstickz64/golox/token"
+)
+
+func stmtToJson(node Stmt) any {
+	switch node := node.(type) {
+	case *BlockStmt:
+		if node == nil {
+			return nil
+		}
+
+		return map[string]any{
+			"type":       "BlockStmt",
+			"statements": sliceToJson(node.Statements, func(item Stmt) any { return stmtToJson(item) }),
+		}
+	case *ClassStmt:
+		if node == nil {
+			return nil
+		}
+
+		return map[string]any{
+			"type":       "ClassStmt",
+			"name":       tokenToJson(node.Name),
+			"superClass": exprToJson(node.SuperClass),
+			"methods":    sliceToJson(node.Methods, func(item *FunctionStmt) any { return stmtToJson(item) }),
+		}
+	case *ExpressionStmt:
+		if node == nil {
+			return nil
+		}
+
+		return map[string]any{
+			"type":       "ExpressionStmt",
+			"expression": exprToJson(node.Expression),
+		}
+	case *WhileStmt:
+		if node == nil {
+			return nil
+		}
+
+		return map[string]any{
+			"type":      "WhileStmt",
+			"condition": exprToJson(node.Condition),
+			"body":      stmtToJson(node.Body),
+		}
+	case *IfStmt:
+		if node == nil {
+			return nil
+		}
+
+		return map[string]any{
+			"type":       "IfStmt",
+			"condition":  exprToJson(node.Condition),
+			"thenBranch": stmtToJson(node.ThenBranch),
+			"elseBranch": stmtToJson(node.ElseBranch),
+		}
+	case *PrintStmt:
+		if node == nil {
+			return nil
+		}
+
+		return map[string]any{
+			"type":       "PrintStmt",
+			"expression": exprToJson(node.Expression),
+		}
+	case *ReturnStmt:
+		if node == nil {
+			return nil
+		}
+
+		return map[string]any{
+			"type":    "ReturnStmt",
+			"keyword": tokenToJson(node.Keyword),
+			"value":   exprToJson(node.Value),
+		}
+	case *VarStmt:
+		if node == nil {
+			return nil
+		}
+
+		return map[string]any{
+			"type":        "VarStmt",
+			"name":        tokenToJson(node.Name),
+			"initializer": exprToJson(node.Initializer),
+		}
+	case *FunctionStmt:
+		if node == nil {
+			return nil
+		}
+
+		return map[string]any{
+			"type":       "FunctionStmt",
+			"name":       tokenToJson(node.Name),
+			"parameters": sliceToJson(node.Parameters, func(item token.Token) any { return tokenToJson(item) }),
+			"body":       sliceToJson(node.Body, func(item Stmt) any { return stmtToJson(item) }),
+		}
+	}
+
+	return nil
+}
+
+func stmtFromJson(data any) (Stmt, error) {
+	if data == nil {
+		return nil, nil
+	}
+
+	fields, kind, err := nodeFields(data)
+	if err != nil {
+		return nil, err
+	}
+
+	switch kind {
+	case "BlockStmt":
+		statements, err := sliceFromJson(fields["statements"], func(item any) (Stmt, error) { return requiredNode(stmtFromJson(item)) })
+		if err != nil {
+			return nil, fmt.Errorf("BlockStmt.statements: %w", err)
+		}
+
+		return &BlockStmt{
+			Statements: statements,
+		}, nil
+	case "ClassStmt":
+		name, err := tokenFromJson(fields["name"])
+		if err != nil {
+			return nil, fmt.Errorf("ClassStmt.name: %w", err)
+		}
+
+		superClass, err := castNode[*VariableExpr](exprFromJson(fields["superClass"]))
+		if err != nil {
+			return nil, fmt.Errorf("ClassStmt.superClass: %w", err)
+		}
+
+		methods, err := sliceFromJson(fields["methods"], func(item any) (*FunctionStmt, error) {
+			return requiredNode(castNode[*FunctionStmt](stmtFromJson(item)))
+		})
+		if err != nil {
+			return nil, fmt.Errorf("ClassStmt.methods: %w", err)
+		}
+
+		return &ClassStmt{
+			Name:       name,
+			SuperClass: superClass,
+			Methods:    methods,
+		}, nil
+	case "ExpressionStmt":
+		expression, err := requiredNode(exprFromJson(fields["expression"]))
+		if err != nil {
+			return nil, fmt.Errorf("ExpressionStmt.expression: %w", err)
+		}
+
+		return &ExpressionStmt{
+			Expression: expression,
+		}, nil
+	case "WhileStmt":
+		condition, err := requiredNode(exprFromJson(fields["condition"]))
+		if err != nil {
+			return nil, fmt.Errorf("WhileStmt.condition: %w", err)
+		}
+
+		body, err := requiredNode(stmtFromJson(fields["body"]))
+		if err != nil {
+			return nil, fmt.Errorf("WhileStmt.body: %w", err)
+		}
+
+		return &WhileStmt{
+			Condition: condition,
+			Body:      body,
+		}, nil
+	case "IfStmt":
+		condition, err := requiredNode(exprFromJson(fields["condition"]))
+		if err != nil {
+			return nil, fmt.Errorf("IfStmt.condition: %w", err)
+		}
+
+		thenBranch, err := requiredNode(stmtFromJson(fields["thenBranch"]))
+		if err != nil {
+			return nil, fmt.Errorf("IfStmt.thenBranch: %w", err)
+		}
+
+		elseBranch, err := stmtFromJson(fields["elseBranch"])
+		if err != nil {
+			return nil, fmt.Errorf("IfStmt.elseBranch: %w", err)
+		}
+
+		return &IfStmt{
+			Condition:  condition,
+			ThenBranch: thenBranch,
+			ElseBranch: elseBranch,
+		}, nil
+	case "PrintStmt":
+		expression, err := requiredNode(exprFromJson(fields["expression"]))
+		if err != nil {
+			return nil, fmt.Errorf("PrintStmt.expression: %w", err)
+		}
+
+		return &PrintStmt{
+			Expression: expression,
+		}, nil
+	case "ReturnStmt":
+		keyword, err := tokenFromJson(fields["keyword"])
+		if err != nil {
+			return nil, fmt.Errorf("ReturnStmt.keyword: %w", err)
+		}
+
+		value, err := exprFromJson(fields["value"])
+		if err != nil {
+			return nil, fmt.Errorf("ReturnStmt.value: %w", err)
+		}
+
+		return &ReturnStmt{
+			Keyword: keyword,
+			Value:   value,
+		}, nil
+	case "VarStmt":
+		name, err := tokenFromJson(fields["name"])
+		if err != nil {
+			return nil, fmt.Errorf("VarStmt.name: %w", err)
+		}
+
+		initializer, err := exprFromJson(fields["initializer"])
+		if err != nil {
+			return nil, fmt.Errorf("VarStmt.initializer: %w", err)
+		}
+
+		return &VarStmt{
+			Name:        name,
+			Initializer: initializer,
+		}, nil
+	case "FunctionStmt":
+		name, err := tokenFromJson(fields["name"])
+		if err != nil {
+			return nil, fmt.Errorf("FunctionStmt.name: %w", err)
+		}
+
+		parameters, err := sliceFromJson(fields["parameters"], func(item any) (token.Token, error) { return tokenFromJson(item) })
+		if err != nil {
+			return nil, fmt.Errorf("FunctionStmt.parameters: %w", err)
+		}
+
+		body, err := sliceFromJson(fields["body"], func(item any) (Stmt, error) { return requiredNode(stmtFromJson(item)) })
+		if err != nil {
+			return nil, fmt.Errorf("FunctionStmt.body: %w", err)
+		}
+
+		return &FunctionStmt{
+			Name:       name,
+			Parameters: parameters,
+			Body:       body,
+		}, nil
+	}
+
+	return nil, fmt.Errorf("unknown stmt type '%s'", kind)
+}
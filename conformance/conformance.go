@@ -0,0 +1,245 @@
+package conformance
+
+import (
+	"bytes"
+	goerrors "errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// exit codes of the interpreter, as used by RunFile in main
+const (
+	EXIT_CODE_OK            = 0
+	EXIT_CODE_COMPILE_ERROR = 65
+	EXIT_CODE_RUNTIME_ERROR = 70
+)
+
+// annotations written in test files, in the style of the craftinginterpreters test suite
+var (
+	expectedOutputPattern       = regexp.MustCompile(`// expect: ?(.*)`)
+	expectedRuntimeErrorPattern = regexp.MustCompile(`// expect runtime error: (.+)`)
+	// errors are reported on the line of the annotation, unless it names a line.
+	// Annotations for other implementations, like "[c line 3]", are ignored
+	expectedErrorPattern     = regexp.MustCompile(`// (Error.*)`)
+	expectedLineErrorPattern = regexp.MustCompile(`// \[(?:java )?line (\d+)\] (Error.*)`)
+)
+
+// errors written by the interpreter to stderr
+var (
+	compileErrorPattern = regexp.MustCompile(`^\[line (\d+):\d+\] (Error.*)$`)
+	runtimeErrorPattern = regexp.MustCompile(`^encountered a runtime error: (.*)$`)
+	stackTracePattern   = regexp.MustCompile(`^\[on (\d+):\d+\]$`)
+)
+
+// the behaviour a test file expects from the interpreter
+type Expectations struct {
+	// lines printed to stdout
+	Output []Line
+	// compile errors, like "[line 3] Error at 'x': message"
+	Errors []string
+	// nil if no runtime error is expected
+	RuntimeError *Line
+	ExitCode     int
+}
+
+// text expected on a line of the test file
+type Line struct {
+	Line int
+	Text string
+}
+
+// ParseExpectations reads the annotations in the source of a test file
+func ParseExpectations(source string) Expectations {
+	expectations := Expectations{}
+	for i, line := range strings.Split(source, "\n") {
+		number := i + 1
+		if match := expectedOutputPattern.FindStringSubmatch(line); match != nil {
+			expectations.Output = append(expectations.Output, Line{Line: number, Text: match[1]})
+			continue
+		}
+
+		if match := expectedLineErrorPattern.FindStringSubmatch(line); match != nil {
+			expectations.Errors = append(expectations.Errors, fmt.Sprintf("[line %s] %s", match[1], match[2]))
+			expectations.ExitCode = EXIT_CODE_COMPILE_ERROR
+			continue
+		}
+
+		if match := expectedErrorPattern.FindStringSubmatch(line); match != nil {
+			expectations.Errors = append(expectations.Errors, fmt.Sprintf("[line %d] %s", number, match[1]))
+			expectations.ExitCode = EXIT_CODE_COMPILE_ERROR
+			continue
+		}
+
+		if match := expectedRuntimeErrorPattern.FindStringSubmatch(line); match != nil {
+			expectations.RuntimeError = &Line{Line: number, Text: match[1]}
+			expectations.ExitCode = EXIT_CODE_RUNTIME_ERROR
+		}
+	}
+
+	return expectations
+}
+
+// what the interpreter did when running a test file
+type Outcome struct {
+	Stdout   string
+	Stderr   string
+	ExitCode int
+}
+
+// Check compares an outcome with the expectations, and returns a description of every difference
+func Check(expectations Expectations, outcome Outcome) []string {
+	failures := []string{}
+
+	stdout := strings.Split(strings.TrimSuffix(outcome.Stdout, "\n"), "\n")
+	if outcome.Stdout == "" {
+		stdout = nil
+	}
+
+	for i, expected := range expectations.Output {
+		if i >= len(stdout) {
+			failures = append(failures, fmt.Sprintf("missing output '%s' on line %d", expected.Text, expected.Line))
+		} else if stdout[i] != expected.Text {
+			failures = append(failures, fmt.Sprintf("expected output '%s' on line %d and got '%s'", expected.Text, expected.Line, stdout[i]))
+		}
+	}
+
+	for _, unexpected := range stdout[min(len(expectations.Output), len(stdout)):] {
+		failures = append(failures, fmt.Sprintf("got unexpected output '%s'", unexpected))
+	}
+
+	stderr := strings.Split(strings.TrimSuffix(outcome.Stderr, "\n"), "\n")
+	if outcome.Stderr == "" {
+		stderr = nil
+	}
+
+	if expectations.RuntimeError != nil {
+		failures = append(failures, checkRuntimeError(*expectations.RuntimeError, stderr)...)
+	} else {
+		failures = append(failures, checkCompileErrors(expectations.Errors, stderr)...)
+	}
+
+	if outcome.ExitCode != expectations.ExitCode {
+		failures = append(failures, fmt.Sprintf("expected exit code %d and got %d", expectations.ExitCode, outcome.ExitCode))
+	}
+
+	return failures
+}
+
+func checkRuntimeError(expected Line, stderr []string) []string {
+	if len(stderr) < 2 {
+		return []string{fmt.Sprintf("expected runtime error '%s' and got:\n%s", expected.Text, strings.Join(stderr, "\n"))}
+	}
+
+	failures := []string{}
+	match := runtimeErrorPattern.FindStringSubmatch(stderr[0])
+	if match == nil || match[1] != expected.Text {
+		failures = append(failures, fmt.Sprintf("expected runtime error '%s' and got '%s'", expected.Text, stderr[0]))
+	}
+
+	match = stackTracePattern.FindStringSubmatch(stderr[1])
+	if match == nil {
+		failures = append(failures, fmt.Sprintf("expected the line of the runtime error and got '%s'", stderr[1]))
+	} else if line, _ := strconv.Atoi(match[1]); line != expected.Line {
+		failures = append(failures, fmt.Sprintf("expected the runtime error on line %d and got line %d", expected.Line, line))
+	}
+
+	return failures
+}
+
+func checkCompileErrors(expected []string, stderr []string) []string {
+	failures := []string{}
+	found := []string{}
+	for _, line := range stderr {
+		match := compileErrorPattern.FindStringSubmatch(line)
+		if match == nil {
+			if line != "" {
+				failures = append(failures, fmt.Sprintf("got unexpected output on stderr '%s'", line))
+			}
+			continue
+		}
+
+		// the interpreter reports columns as well, which the annotations don't have
+		found = append(found, fmt.Sprintf("[line %s] %s", match[1], match[2]))
+	}
+
+	for _, err := range expected {
+		if i := slices.Index(found, err); i >= 0 {
+			found = slices.Delete(found, i, i+1)
+		} else {
+			failures = append(failures, fmt.Sprintf("missing expected error '%s'", err))
+		}
+	}
+
+	for _, err := range found {
+		failures = append(failures, fmt.Sprintf("got unexpected error '%s'", err))
+	}
+
+	return failures
+}
+
+type Result struct {
+	File     string
+	Failures []string
+}
+
+func (r Result) Passed() bool {
+	return len(r.Failures) == 0
+}
+
+// Discover returns the .lox files in paths, sorted. Directories are searched recursively
+func Discover(paths []string) ([]string, error) {
+	files := []string{}
+	for _, pth := range paths {
+		err := filepath.WalkDir(pth, func(pth string, entry fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+
+			if !entry.IsDir() && filepath.Ext(pth) == ".lox" {
+				files = append(files, pth)
+			}
+
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	slices.Sort(files)
+	return slices.Compact(files), nil
+}
+
+// RunFile runs the test file at pth with the interpreter executable, and checks its behaviour
+func RunFile(interpreter string, pth string) Result {
+	source, err := os.ReadFile(pth)
+	if err != nil {
+		return Result{File: pth, Failures: []string{err.Error()}}
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command(interpreter, pth)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	outcome := Outcome{}
+	if err := cmd.Run(); err != nil {
+		var exitErr *exec.ExitError
+		if !goerrors.As(err, &exitErr) {
+			return Result{File: pth, Failures: []string{fmt.Sprintf("failed to run the interpreter: %v", err)}}
+		}
+
+		outcome.ExitCode = exitErr.ExitCode()
+	}
+
+	outcome.Stdout = stdout.String()
+	outcome.Stderr = stderr.String()
+	return Result{File: pth, Failures: Check(ParseExpectations(string(source)), outcome)}
+}
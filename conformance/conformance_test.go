@@ -0,0 +1,54 @@
+package conformance
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+// the interpreter built by TestMain, which the test files are run with
+var interpreterExe string
+
+func TestMain(m *testing.M) {
+	dir, err := os.MkdirTemp("", "golox-conformance")
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	interpreterExe = filepath.Join(dir, "golox")
+	build := exec.Command("go", "build", "-o", interpreterExe, "..")
+	build.Stdout = os.Stdout
+	build.Stderr = os.Stderr
+	if err := build.Run(); err != nil {
+		fmt.Fprintln(os.Stderr, "failed to build the interpreter:", err)
+		os.RemoveAll(dir)
+		os.Exit(1)
+	}
+
+	code := m.Run()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+func TestConformance(t *testing.T) {
+	files, err := Discover([]string{filepath.Join("..", "testdata")})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(files) == 0 {
+		t.Fatal("found no test files in testdata")
+	}
+
+	for _, file := range files {
+		t.Run(filepath.Base(file), func(t *testing.T) {
+			t.Parallel()
+			for _, failure := range RunFile(interpreterExe, file).Failures {
+				t.Error(failure)
+			}
+		})
+	}
+}
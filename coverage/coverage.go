@@ -0,0 +1,217 @@
+package coverage
+
+import (
+	"cmp"
+	"slices"
+
+	"github.com/Drumstickz64/golox/ast"
+	"github.com/Drumstickz64/golox/interpreting"
+	"github.com/Drumstickz64/golox/parsing"
+	"github.com/Drumstickz64/golox/token"
+)
+
+// Recorder is an interpreter observer that counts how many times each statement and branch is executed
+type Recorder struct {
+	statements map[ast.Stmt]int
+	// how many times each branch point went either way, indexed by whether the branch was taken
+	branches map[any]*[2]int
+}
+
+// branches are only reported to observers that implement BranchObserver
+var _ interpreting.BranchObserver = (*Recorder)(nil)
+
+func NewRecorder() *Recorder {
+	return &Recorder{
+		statements: map[ast.Stmt]int{},
+		branches:   map[any]*[2]int{},
+	}
+}
+
+func (r *Recorder) OnStatement(stmt ast.Stmt) {
+	r.statements[stmt]++
+}
+
+func (r *Recorder) OnCall(callee interpreting.Callable, paren token.Token, arguments []any) {}
+
+func (r *Recorder) OnReturn(callee interpreting.Callable, paren token.Token, result any, err error) {}
+
+func (r *Recorder) OnAssignment(name token.Token, value any) {}
+
+func (r *Recorder) OnBranch(node any, taken bool) {
+	counts, ok := r.branches[node]
+	if !ok {
+		counts = &[2]int{}
+		r.branches[node] = counts
+	}
+
+	if taken {
+		counts[1]++
+	} else {
+		counts[0]++
+	}
+}
+
+// a statement written in the source, along with how many times it was executed
+type Statement struct {
+	Start token.Token
+	End   token.Token
+	Count int
+}
+
+// a point where execution goes one of two ways, like an if statement
+type Branch struct {
+	// the token the branch is reported at, like the 'if' keyword or the logical operator.
+	// Branches of loops desugared from for loops are reported at the 'for' keyword
+	Token token.Token
+	// names of both ways, like "then" and "else"
+	TakenName, NotTakenName string
+	Taken, NotTaken         int
+}
+
+// Report is the coverage of a whole program, including the code that never ran
+type Report struct {
+	Filename   string
+	Source     string
+	Statements []Statement
+	Branches   []Branch
+}
+
+// Report builds the coverage of statements, which were parsed from source by parser
+func (r *Recorder) Report(filename, source string, statements []ast.Stmt, parser parsing.Parser) Report {
+	builder := &reportBuilder{
+		recorder: r,
+		spans:    parser.Spans,
+		forLoops: parser.ForLoops,
+		loops:    map[*ast.WhileStmt]token.Token{},
+	}
+
+	for _, loop := range parser.ForLoops {
+		builder.loops[loop.While] = loop.Keyword
+	}
+
+	builder.addStmts(statements)
+
+	slices.SortFunc(builder.report.Statements, func(a, b Statement) int {
+		return comparePositions(a.Start, b.Start)
+	})
+	slices.SortFunc(builder.report.Branches, func(a, b Branch) int {
+		return comparePositions(a.Token, b.Token)
+	})
+
+	builder.report.Filename = filename
+	builder.report.Source = source
+	return builder.report
+}
+
+type reportBuilder struct {
+	recorder *Recorder
+	spans    map[ast.Stmt]ast.Span
+	forLoops map[ast.Stmt]*ast.ForLoop
+	// 'for' keywords of while loops desugared from for loops
+	loops  map[*ast.WhileStmt]token.Token
+	report Report
+}
+
+func (b *reportBuilder) addStmts(stmts []ast.Stmt) {
+	for _, stmt := range stmts {
+		b.addStmt(stmt)
+	}
+}
+
+func (b *reportBuilder) addStmt(stmt ast.Stmt) {
+	// blocks are covered by their statements, unless they were desugared from a for loop
+	_, isBlock := stmt.(*ast.BlockStmt)
+	if span, ok := b.spans[stmt]; ok && (!isBlock || b.forLoops[stmt] != nil) {
+		b.report.Statements = append(b.report.Statements, Statement{
+			Start: span.Start,
+			End:   span.End,
+			Count: b.recorder.statements[stmt],
+		})
+	}
+
+	switch stmt := stmt.(type) {
+	case *ast.BlockStmt:
+		b.addStmts(stmt.Statements)
+	case *ast.ClassStmt:
+		for _, method := range stmt.Methods {
+			b.addStmts(method.Body)
+		}
+	case *ast.FunctionStmt:
+		b.addStmts(stmt.Body)
+	case *ast.ExpressionStmt:
+		b.addExpr(stmt.Expression)
+	case *ast.PrintStmt:
+		b.addExpr(stmt.Expression)
+	case *ast.ReturnStmt:
+		if stmt.Value != nil {
+			b.addExpr(stmt.Value)
+		}
+	case *ast.VarStmt:
+		if stmt.Initializer != nil {
+			b.addExpr(stmt.Initializer)
+		}
+	case *ast.IfStmt:
+		b.addBranch(stmt, b.spans[stmt].Start, "then", "else")
+		b.addExpr(stmt.Condition)
+		b.addStmt(stmt.ThenBranch)
+		if stmt.ElseBranch != nil {
+			b.addStmt(stmt.ElseBranch)
+		}
+	case *ast.WhileStmt:
+		keyword, isFor := b.loops[stmt]
+		if !isFor {
+			keyword = b.spans[stmt].Start
+		}
+
+		b.addBranch(stmt, keyword, "body", "exit")
+		b.addExpr(stmt.Condition)
+		b.addStmt(stmt.Body)
+	}
+}
+
+func (b *reportBuilder) addExpr(expr ast.Expr) {
+	switch expr := expr.(type) {
+	case *ast.LogicalExpr:
+		b.addBranch(expr, expr.Operator, "right operand", "short-circuit")
+		b.addExpr(expr.Left)
+		b.addExpr(expr.Right)
+	case *ast.BinaryExpr:
+		b.addExpr(expr.Left)
+		b.addExpr(expr.Right)
+	case *ast.GroupingExpr:
+		b.addExpr(expr.Expression)
+	case *ast.UnaryExpr:
+		b.addExpr(expr.Right)
+	case *ast.CallExpr:
+		b.addExpr(expr.Callee)
+		for _, argument := range expr.Arguments {
+			b.addExpr(argument)
+		}
+	case *ast.GetExpr:
+		b.addExpr(expr.Object)
+	case *ast.SetExpr:
+		b.addExpr(expr.Object)
+		b.addExpr(expr.Value)
+	case *ast.AssignmentExpr:
+		b.addExpr(expr.Value)
+	}
+}
+
+func (b *reportBuilder) addBranch(node any, tok token.Token, takenName, notTakenName string) {
+	branch := Branch{
+		Token:        tok,
+		TakenName:    takenName,
+		NotTakenName: notTakenName,
+	}
+
+	if counts, ok := b.recorder.branches[node]; ok {
+		branch.NotTaken = counts[0]
+		branch.Taken = counts[1]
+	}
+
+	b.report.Branches = append(b.report.Branches, branch)
+}
+
+func comparePositions(a, b token.Token) int {
+	return cmp.Or(cmp.Compare(a.Line, b.Line), cmp.Compare(a.Column, b.Column))
+}
@@ -0,0 +1,195 @@
+package coverage
+
+import (
+	"fmt"
+	"html"
+	"io"
+	"strings"
+)
+
+// Summary returns how many statements and branch outcomes were executed, out of how many there are
+func (r Report) Summary() (coveredStatements, statements, coveredBranches, branches int) {
+	for _, statement := range r.Statements {
+		if statement.Count > 0 {
+			coveredStatements++
+		}
+	}
+
+	for _, branch := range r.Branches {
+		if branch.Taken > 0 {
+			coveredBranches++
+		}
+
+		if branch.NotTaken > 0 {
+			coveredBranches++
+		}
+	}
+
+	return coveredStatements, len(r.Statements), coveredBranches, len(r.Branches) * 2
+}
+
+// SummaryString describes the coverage in a single line, like "statements: 50.0% (1/2), branches: 100.0% (2/2)"
+func (r Report) SummaryString() string {
+	coveredStatements, statements, coveredBranches, branches := r.Summary()
+	return fmt.Sprintf("statements: %.1f%% (%d/%d), branches: %.1f%% (%d/%d)",
+		percentage(coveredStatements, statements), coveredStatements, statements,
+		percentage(coveredBranches, branches), coveredBranches, branches)
+}
+
+// WriteProfile writes the coverage of every statement in the format of Go's cover profiles,
+// one statement per line with its position, its number of statements and how many times it ran
+func (r Report) WriteProfile(w io.Writer) error {
+	profile := strings.Builder{}
+	profile.WriteString("mode: count\n")
+	for _, statement := range r.Statements {
+		// token columns are where the token ends, Go uses the column after the end
+		startColumn := statement.Start.Column - len(statement.Start.Lexeme) + 1
+		fmt.Fprintf(&profile, "%s:%d.%d,%d.%d 1 %d\n",
+			r.Filename, statement.Start.Line, startColumn, statement.End.Line, statement.End.Column+1, statement.Count)
+	}
+
+	_, err := io.WriteString(w, profile.String())
+	return err
+}
+
+// WriteLcov writes the coverage of every line and branch in the LCOV tracefile format
+func (r Report) WriteLcov(w io.Writer) error {
+	lcov := strings.Builder{}
+	lcov.WriteString("TN:\n")
+	fmt.Fprintf(&lcov, "SF:%s\n", r.Filename)
+
+	lines := r.lineCounts()
+	hitLines := 0
+	for line := 1; line <= r.lineCount(); line++ {
+		count, ok := lines[line]
+		if !ok {
+			continue
+		}
+
+		if count > 0 {
+			hitLines++
+		}
+		fmt.Fprintf(&lcov, "DA:%d,%d\n", line, count)
+	}
+	fmt.Fprintf(&lcov, "LF:%d\n", len(lines))
+	fmt.Fprintf(&lcov, "LH:%d\n", hitLines)
+
+	for block, branch := range r.Branches {
+		// branch points that were never reached are written as '-'
+		taken, notTaken := fmt.Sprint(branch.Taken), fmt.Sprint(branch.NotTaken)
+		if branch.Taken == 0 && branch.NotTaken == 0 {
+			taken, notTaken = "-", "-"
+		}
+
+		fmt.Fprintf(&lcov, "BRDA:%d,%d,0,%s\n", branch.Token.Line, block, taken)
+		fmt.Fprintf(&lcov, "BRDA:%d,%d,1,%s\n", branch.Token.Line, block, notTaken)
+	}
+
+	_, _, coveredBranches, branches := r.Summary()
+	fmt.Fprintf(&lcov, "BRF:%d\n", branches)
+	fmt.Fprintf(&lcov, "BRH:%d\n", coveredBranches)
+	lcov.WriteString("end_of_record\n")
+
+	_, err := io.WriteString(w, lcov.String())
+	return err
+}
+
+const htmlStyle = `body { font-family: sans-serif; }
+table { border-collapse: collapse; font-family: monospace; }
+td { padding: 0 8px; white-space: pre; }
+td.number { color: #888; text-align: right; }
+tr.covered { background: #dfd; }
+tr.partial { background: #ffc; }
+tr.uncovered { background: #fdd; }`
+
+// WriteHtml writes a page showing the source with every line colored by its coverage.
+// Hovering a line with branches shows how many times each way was taken
+func (r Report) WriteHtml(w io.Writer) error {
+	page := strings.Builder{}
+	title := html.EscapeString("Coverage of " + r.Filename)
+	fmt.Fprintf(&page, "<!DOCTYPE html>\n<html>\n<head>\n<meta charset=\"utf-8\">\n<title>%s</title>\n", title)
+	fmt.Fprintf(&page, "<style>\n%s\n</style>\n</head>\n<body>\n", htmlStyle)
+	fmt.Fprintf(&page, "<h1>%s</h1>\n<p>%s</p>\n<table>\n", title, html.EscapeString(r.SummaryString()))
+
+	lines := r.lineCounts()
+	partial := r.partialLines()
+	branches := r.lineBranches()
+	for i, source := range strings.Split(r.Source, "\n") {
+		line := i + 1
+		count, hasStatements := lines[line]
+
+		class := ""
+		switch {
+		case hasStatements && count == 0:
+			class = "uncovered"
+		case partial[line]:
+			class = "partial"
+		case hasStatements:
+			class = "covered"
+		}
+
+		countText := ""
+		if hasStatements {
+			countText = fmt.Sprint(count)
+		}
+
+		fmt.Fprintf(&page, "<tr class=\"%s\" title=\"%s\"><td class=\"number\">%d</td><td class=\"number\">%s</td><td>%s</td></tr>\n",
+			class, html.EscapeString(strings.Join(branches[line], "\n")), line, countText, html.EscapeString(source))
+	}
+
+	page.WriteString("</table>\n</body>\n</html>\n")
+
+	_, err := io.WriteString(w, page.String())
+	return err
+}
+
+// maps the lines where statements start to the highest number of times one of them ran
+func (r Report) lineCounts() map[int]int {
+	lines := map[int]int{}
+	for _, statement := range r.Statements {
+		lines[statement.Start.Line] = max(lines[statement.Start.Line], statement.Count)
+	}
+
+	return lines
+}
+
+// lines that ran, but have a statement that didn't, or a branch that wasn't taken both ways
+func (r Report) partialLines() map[int]bool {
+	partial := map[int]bool{}
+	for _, statement := range r.Statements {
+		if statement.Count == 0 {
+			partial[statement.Start.Line] = true
+		}
+	}
+
+	for _, branch := range r.Branches {
+		if branch.Taken == 0 || branch.NotTaken == 0 {
+			partial[branch.Token.Line] = true
+		}
+	}
+
+	return partial
+}
+
+// describes how many times each branch on every line went either way
+func (r Report) lineBranches() map[int][]string {
+	branches := map[int][]string{}
+	for _, branch := range r.Branches {
+		branches[branch.Token.Line] = append(branches[branch.Token.Line], fmt.Sprintf("%s: %s %d, %s %d",
+			branch.Token.Lexeme, branch.TakenName, branch.Taken, branch.NotTakenName, branch.NotTaken))
+	}
+
+	return branches
+}
+
+func (r Report) lineCount() int {
+	return strings.Count(r.Source, "\n") + 1
+}
+
+func percentage(covered, total int) float64 {
+	if total == 0 {
+		return 100
+	}
+
+	return float64(covered) / float64(total) * 100
+}
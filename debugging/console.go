@@ -0,0 +1,220 @@
+package debugging
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/Drumstickz64/golox/environment"
+	"github.com/Drumstickz64/golox/interpreting"
+)
+
+const consoleHelp = `commands:
+  c, continue        continue until the next breakpoint
+  s, step            step into the next statement
+  n, next            step over calls to the next statement
+  o, out             step out of the current function
+  b, break LINE      set a breakpoint
+  d, delete LINE     delete a breakpoint
+  breakpoints        list breakpoints
+  bt, stack          print the call stack
+  f, frame N         select a frame of the call stack
+  v, vars            print the variables in every scope of the selected frame
+  p, print NAME      print a variable visible from the selected frame
+  l, list            print the source around the current line
+  q, quit            stop the program
+  h, help            print this message`
+
+// Console is a frontend that reads commands from a terminal
+type Console struct {
+	reader *bufio.Reader
+	out    io.Writer
+	lines  []string
+	// globals that exist before the program runs, which are left out when printing variables
+	natives map[string]bool
+
+	stop Stop
+	// index in the call stack of the frame used for printing variables
+	frame int
+}
+
+func NewConsole(in io.Reader, out io.Writer, source string, natives []string) *Console {
+	console := &Console{
+		reader:  bufio.NewReader(in),
+		out:     out,
+		lines:   strings.Split(source, "\n"),
+		natives: map[string]bool{},
+	}
+
+	for _, name := range natives {
+		console.natives[name] = true
+	}
+
+	return console
+}
+
+func (c *Console) Stopped(debugger *Debugger, stop Stop) (Command, error) {
+	c.stop = stop
+	c.frame = 0
+	fmt.Fprintf(c.out, "stopped at line %d (%s)\n", stop.Line, stop.Reason)
+	c.printLine(stop.Line, true)
+
+	for {
+		fmt.Fprint(c.out, "(debug) ")
+		input, err := c.reader.ReadString('\n')
+		if err == io.EOF && input == "" {
+			fmt.Fprintln(c.out)
+			return COMMAND_CONTINUE, ErrTerminated
+		}
+
+		if err != nil && err != io.EOF {
+			return COMMAND_CONTINUE, err
+		}
+
+		fields := strings.Fields(input)
+		if len(fields) == 0 {
+			continue
+		}
+
+		name, args := fields[0], fields[1:]
+		switch name {
+		case "c", "continue":
+			return COMMAND_CONTINUE, nil
+		case "s", "step":
+			return COMMAND_STEP_IN, nil
+		case "n", "next":
+			return COMMAND_STEP_OVER, nil
+		case "o", "out":
+			return COMMAND_STEP_OUT, nil
+		case "q", "quit":
+			return COMMAND_CONTINUE, ErrTerminated
+		case "b", "break":
+			if line, ok := c.lineArgument(args); ok {
+				debugger.AddBreakpoint(line)
+				fmt.Fprintf(c.out, "breakpoint set on line %d\n", line)
+			}
+		case "d", "delete":
+			if line, ok := c.lineArgument(args); ok {
+				if debugger.RemoveBreakpoint(line) {
+					fmt.Fprintf(c.out, "breakpoint deleted on line %d\n", line)
+				} else {
+					fmt.Fprintf(c.out, "no breakpoint on line %d\n", line)
+				}
+			}
+		case "breakpoints":
+			for _, line := range debugger.Breakpoints() {
+				c.printLine(line, false)
+			}
+		case "bt", "stack":
+			c.printStack()
+		case "f", "frame":
+			index, err := strconv.Atoi(strings.Join(args, ""))
+			if err != nil || index < 0 || index >= len(stop.Frames) {
+				fmt.Fprintf(c.out, "expected a frame between 0 and %d\n", len(stop.Frames)-1)
+				continue
+			}
+
+			c.frame = index
+			c.printStack()
+		case "v", "vars":
+			c.printVariables()
+		case "p", "print":
+			if len(args) != 1 {
+				fmt.Fprintln(c.out, "expected a variable name")
+				continue
+			}
+
+			c.printVariable(args[0])
+		case "l", "list":
+			for line := max(stop.Line-3, 1); line <= min(stop.Line+3, len(c.lines)); line++ {
+				c.printLine(line, line == stop.Line)
+			}
+		case "h", "help":
+			fmt.Fprintln(c.out, consoleHelp)
+		default:
+			fmt.Fprintf(c.out, "unknown command '%s', type 'help' for a list of commands\n", name)
+		}
+	}
+}
+
+func (c *Console) lineArgument(args []string) (int, bool) {
+	line, err := strconv.Atoi(strings.Join(args, ""))
+	if err != nil || line < 1 || line > len(c.lines) {
+		fmt.Fprintf(c.out, "expected a line between 1 and %d\n", len(c.lines))
+		return 0, false
+	}
+
+	return line, true
+}
+
+func (c *Console) printLine(line int, isCurrent bool) {
+	if line < 1 || line > len(c.lines) {
+		return
+	}
+
+	marker := " "
+	if isCurrent {
+		marker = ">"
+	}
+
+	fmt.Fprintf(c.out, "%s %4d | %s\n", marker, line, c.lines[line-1])
+}
+
+func (c *Console) printStack() {
+	for i, frame := range c.stop.Frames {
+		marker := " "
+		if i == c.frame {
+			marker = ">"
+		}
+
+		fmt.Fprintf(c.out, "%s #%d %s", marker, i, frame.Name)
+		if frame.Call.Line > 0 {
+			fmt.Fprintf(c.out, ", called on line %d", frame.Call.Line)
+		}
+		fmt.Fprintln(c.out)
+	}
+}
+
+func (c *Console) printVariables() {
+	env := c.stop.Frames[c.frame].Env
+	for depth := 0; env != nil; depth++ {
+		if env.Enclosing() == nil {
+			fmt.Fprintln(c.out, "globals:")
+		} else {
+			fmt.Fprintf(c.out, "scope %d:\n", depth)
+		}
+
+		for _, name := range env.Names() {
+			if env.Enclosing() == nil && c.natives[name] {
+				continue
+			}
+
+			value, _ := env.Value(name)
+			fmt.Fprintf(c.out, "  %s = %s\n", name, interpreting.Stringify(value))
+		}
+
+		env = env.Enclosing()
+	}
+}
+
+func (c *Console) printVariable(name string) {
+	if value, ok := lookup(c.stop.Frames[c.frame].Env, name); ok {
+		fmt.Fprintf(c.out, "%s = %s\n", name, interpreting.Stringify(value))
+		return
+	}
+
+	fmt.Fprintf(c.out, "undefined variable '%s'\n", name)
+}
+
+// finds the innermost variable called name in env and its enclosing environments
+func lookup(env *environment.Environment, name string) (any, bool) {
+	for ; env != nil; env = env.Enclosing() {
+		if value, ok := env.Value(name); ok {
+			return value, true
+		}
+	}
+
+	return nil, false
+}
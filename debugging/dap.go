@@ -0,0 +1,511 @@
+package debugging
+
+import (
+	"bufio"
+	"encoding/json"
+	goerrors "errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/Drumstickz64/golox/environment"
+	"github.com/Drumstickz64/golox/interpreting"
+	"github.com/Drumstickz64/golox/parsing"
+)
+
+// the interpreter runs on a single thread
+const dapThreadId = 1
+
+type dapRequest struct {
+	Seq       int             `json:"seq"`
+	Command   string          `json:"command"`
+	Arguments json.RawMessage `json:"arguments"`
+}
+
+type dapResponse struct {
+	Seq        int    `json:"seq"`
+	Type       string `json:"type"`
+	RequestSeq int    `json:"request_seq"`
+	Success    bool   `json:"success"`
+	Command    string `json:"command"`
+	Message    string `json:"message,omitempty"`
+	Body       any    `json:"body,omitempty"`
+}
+
+type dapEvent struct {
+	Seq   int    `json:"seq"`
+	Type  string `json:"type"`
+	Event string `json:"event"`
+	Body  any    `json:"body,omitempty"`
+}
+
+type dapSource struct {
+	Name string `json:"name"`
+	Path string `json:"path"`
+}
+
+// sent to the interpreter's goroutine to resume a stopped program
+type dapResume struct {
+	command Command
+	err     error
+}
+
+// a Debug Adapter Protocol session for a single program, run on its own goroutine once the client is configured
+type dapSession struct {
+	reader   *bufio.Reader
+	writer   io.Writer
+	writeMu  sync.Mutex
+	seq      int
+	source   dapSource
+	debugger *Debugger
+	// lines that have a statement the debugger can stop at
+	breakableLines map[int]bool
+	natives        map[string]bool
+	run            func(debugger *Debugger) error
+	hasStarted     bool
+
+	// guards the state of a stopped program, which is read by requests from the client
+	mu        sync.Mutex
+	isStopped bool
+	stop      Stop
+	// scopes handed out to the client by the scopes request, a reference is an index plus one
+	scopes  []*environment.Environment
+	resumes chan dapResume
+	// only sent after the response to the request that resumed the program, so it can't be preceded by a new stop
+	pendingResume *dapResume
+}
+
+// ServeDap speaks the Debug Adapter Protocol over conn until the client disconnects.
+// run is called on a new goroutine with a debugger attached to the program once the client has set its breakpoints,
+// natives are the globals left out when showing variables
+func ServeDap(conn io.ReadWriter, path string, parser parsing.Parser, natives []string, run func(debugger *Debugger) error) error {
+	absolute, err := filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+
+	session := &dapSession{
+		reader:         bufio.NewReader(conn),
+		writer:         conn,
+		source:         dapSource{Name: filepath.Base(path), Path: absolute},
+		breakableLines: map[int]bool{},
+		natives:        map[string]bool{},
+		run:            run,
+		resumes:        make(chan dapResume),
+	}
+	session.debugger = NewDebugger(session, parser)
+
+	for stmt, span := range parser.Spans {
+		if session.debugger.Breakable(stmt) {
+			session.breakableLines[span.Start.Line] = true
+		}
+	}
+
+	for _, name := range natives {
+		session.natives[name] = true
+	}
+
+	return session.serve()
+}
+
+func (s *dapSession) serve() error {
+	for {
+		content, err := readFrame(s.reader)
+		if err == io.EOF {
+			s.terminate()
+			s.sendPendingResume()
+			return nil
+		}
+
+		if err != nil {
+			return err
+		}
+
+		request := dapRequest{}
+		if err := json.Unmarshal(content, &request); err != nil {
+			return fmt.Errorf("invalid message: %w", err)
+		}
+
+		body, err := s.handle(request)
+		response := dapResponse{
+			Type:       "response",
+			RequestSeq: request.Seq,
+			Success:    err == nil,
+			Command:    request.Command,
+			Body:       body,
+		}
+		if err != nil {
+			response.Message = err.Error()
+		}
+
+		if err := s.send(&response); err != nil {
+			return err
+		}
+		s.sendPendingResume()
+
+		switch request.Command {
+		case "initialize":
+			if err := s.sendEvent("initialized", nil); err != nil {
+				return err
+			}
+		case "disconnect":
+			return nil
+		}
+	}
+}
+
+func (s *dapSession) handle(request dapRequest) (any, error) {
+	switch request.Command {
+	case "initialize":
+		return map[string]any{
+			"supportsConfigurationDoneRequest": true,
+			"supportsEvaluateForHovers":        true,
+			"supportsTerminateRequest":         true,
+		}, nil
+	case "launch", "attach":
+		arguments := struct {
+			StopOnEntry bool `json:"stopOnEntry"`
+		}{}
+		if err := decodeArguments(request, &arguments); err != nil {
+			return nil, err
+		}
+
+		s.debugger.SetStopOnEntry(arguments.StopOnEntry)
+		return nil, nil
+	case "setBreakpoints":
+		arguments := struct {
+			Breakpoints []struct {
+				Line int `json:"line"`
+			} `json:"breakpoints"`
+		}{}
+		if err := decodeArguments(request, &arguments); err != nil {
+			return nil, err
+		}
+
+		lines := []int{}
+		breakpoints := []map[string]any{}
+		for _, breakpoint := range arguments.Breakpoints {
+			lines = append(lines, breakpoint.Line)
+			breakpoints = append(breakpoints, map[string]any{
+				"verified": s.breakableLines[breakpoint.Line],
+				"line":     breakpoint.Line,
+			})
+		}
+
+		s.debugger.SetBreakpoints(lines)
+		return map[string]any{"breakpoints": breakpoints}, nil
+	case "setExceptionBreakpoints":
+		return map[string]any{"breakpoints": []any{}}, nil
+	case "configurationDone":
+		if !s.hasStarted {
+			s.hasStarted = true
+			go s.runProgram()
+		}
+
+		return nil, nil
+	case "threads":
+		return map[string]any{
+			"threads": []map[string]any{{"id": dapThreadId, "name": "main"}},
+		}, nil
+	case "stackTrace":
+		return s.stackTrace()
+	case "scopes":
+		arguments := struct {
+			FrameId int `json:"frameId"`
+		}{}
+		if err := decodeArguments(request, &arguments); err != nil {
+			return nil, err
+		}
+
+		return s.scopesOf(arguments.FrameId)
+	case "variables":
+		arguments := struct {
+			VariablesReference int `json:"variablesReference"`
+		}{}
+		if err := decodeArguments(request, &arguments); err != nil {
+			return nil, err
+		}
+
+		return s.variables(arguments.VariablesReference)
+	case "evaluate":
+		arguments := struct {
+			Expression string `json:"expression"`
+			FrameId    int    `json:"frameId"`
+		}{}
+		if err := decodeArguments(request, &arguments); err != nil {
+			return nil, err
+		}
+
+		return s.evaluate(strings.TrimSpace(arguments.Expression), arguments.FrameId)
+	case "continue":
+		return map[string]any{"allThreadsContinued": true}, s.resume(COMMAND_CONTINUE, nil)
+	case "next":
+		return nil, s.resume(COMMAND_STEP_OVER, nil)
+	case "stepIn":
+		return nil, s.resume(COMMAND_STEP_IN, nil)
+	case "stepOut":
+		return nil, s.resume(COMMAND_STEP_OUT, nil)
+	case "pause":
+		s.debugger.Pause()
+		return nil, nil
+	case "disconnect", "terminate":
+		s.terminate()
+		return nil, nil
+	}
+
+	return nil, fmt.Errorf("unsupported request '%s'", request.Command)
+}
+
+func (s *dapSession) runProgram() {
+	err := s.run(s.debugger)
+
+	exitCode := 0
+	var exitErr *interpreting.ExitError
+	if goerrors.As(err, &exitErr) {
+		exitCode = exitErr.Code
+	} else if err != nil && err != ErrTerminated {
+		exitCode = 70
+		s.sendEvent("output", map[string]any{"category": "stderr", "output": err.Error() + "\n"})
+	}
+
+	s.sendEvent("exited", map[string]any{"exitCode": exitCode})
+	s.sendEvent("terminated", nil)
+}
+
+// Stopped is called on the program's goroutine, and waits for the client to resume it
+func (s *dapSession) Stopped(debugger *Debugger, stop Stop) (Command, error) {
+	s.mu.Lock()
+	s.isStopped = true
+	s.stop = stop
+	s.scopes = nil
+	s.mu.Unlock()
+
+	if err := s.sendEvent("stopped", map[string]any{
+		"reason":            stop.Reason,
+		"threadId":          dapThreadId,
+		"allThreadsStopped": true,
+	}); err != nil {
+		return COMMAND_CONTINUE, err
+	}
+
+	resume := <-s.resumes
+	return resume.command, resume.err
+}
+
+func (s *dapSession) resume(command Command, err error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.isStopped {
+		return fmt.Errorf("the program isn't stopped")
+	}
+
+	s.isStopped = false
+	s.pendingResume = &dapResume{command: command, err: err}
+	return nil
+}
+
+func (s *dapSession) sendPendingResume() {
+	if s.pendingResume != nil {
+		s.resumes <- *s.pendingResume
+		s.pendingResume = nil
+	}
+}
+
+func (s *dapSession) terminate() {
+	s.debugger.Terminate()
+	s.resume(COMMAND_CONTINUE, ErrTerminated)
+}
+
+func (s *dapSession) stackTrace() (any, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.isStopped {
+		return nil, fmt.Errorf("the program isn't stopped")
+	}
+
+	frames := []map[string]any{}
+	for i, frame := range s.stop.Frames {
+		line := s.stop.Line
+		if i > 0 {
+			line = s.frameLine(frame)
+		}
+
+		frames = append(frames, map[string]any{
+			"id":     i,
+			"name":   frame.Name,
+			"source": s.source,
+			"line":   line,
+			"column": 1,
+		})
+	}
+
+	return map[string]any{"stackFrames": frames, "totalFrames": len(frames)}, nil
+}
+
+// the line a caller's frame is stopped at, which is the statement making the call
+func (s *dapSession) frameLine(frame interpreting.Frame) int {
+	if span, ok := s.debugger.spans[frame.Stmt]; ok {
+		return span.Start.Line
+	}
+
+	return frame.Call.Line
+}
+
+func (s *dapSession) scopesOf(frameId int) (any, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	frame, err := s.frame(frameId)
+	if err != nil {
+		return nil, err
+	}
+
+	scopes := []map[string]any{}
+	for env := frame.Env; env != nil; env = env.Enclosing() {
+		name := "Block"
+		switch {
+		case env.Enclosing() == nil:
+			name = "Globals"
+		case env == frame.Env:
+			name = "Locals"
+		}
+
+		s.scopes = append(s.scopes, env)
+		scopes = append(scopes, map[string]any{
+			"name":               name,
+			"variablesReference": len(s.scopes),
+			"expensive":          false,
+		})
+	}
+
+	return map[string]any{"scopes": scopes}, nil
+}
+
+func (s *dapSession) variables(reference int) (any, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if reference < 1 || reference > len(s.scopes) {
+		return nil, fmt.Errorf("unknown variables reference %d", reference)
+	}
+
+	env := s.scopes[reference-1]
+	variables := []map[string]any{}
+	for _, name := range env.Names() {
+		if env.Enclosing() == nil && s.natives[name] {
+			continue
+		}
+
+		value, _ := env.Value(name)
+		variables = append(variables, map[string]any{
+			"name":               name,
+			"value":              interpreting.Stringify(value),
+			"variablesReference": 0,
+		})
+	}
+
+	return map[string]any{"variables": variables}, nil
+}
+
+// only variable names can be evaluated
+func (s *dapSession) evaluate(expression string, frameId int) (any, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	frame, err := s.frame(frameId)
+	if err != nil {
+		return nil, err
+	}
+
+	value, ok := lookup(frame.Env, expression)
+	if !ok {
+		return nil, fmt.Errorf("undefined variable '%s'", expression)
+	}
+
+	return map[string]any{"result": interpreting.Stringify(value), "variablesReference": 0}, nil
+}
+
+func (s *dapSession) frame(frameId int) (interpreting.Frame, error) {
+	if !s.isStopped {
+		return interpreting.Frame{}, fmt.Errorf("the program isn't stopped")
+	}
+
+	if frameId < 0 || frameId >= len(s.stop.Frames) {
+		return interpreting.Frame{}, fmt.Errorf("unknown frame %d", frameId)
+	}
+
+	return s.stop.Frames[frameId], nil
+}
+
+func (s *dapSession) sendEvent(event string, body any) error {
+	return s.send(&dapEvent{Type: "event", Event: event, Body: body})
+}
+
+// messages are sent from both the client's and the program's goroutines
+func (s *dapSession) send(message any) error {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+
+	s.seq++
+	switch message := message.(type) {
+	case *dapResponse:
+		message.Seq = s.seq
+	case *dapEvent:
+		message.Seq = s.seq
+	}
+
+	content, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+
+	if _, err := fmt.Fprintf(s.writer, "Content-Length: %d\r\n\r\n", len(content)); err != nil {
+		return err
+	}
+
+	_, err = s.writer.Write(content)
+	return err
+}
+
+func decodeArguments(request dapRequest, arguments any) error {
+	if len(request.Arguments) == 0 {
+		return nil
+	}
+
+	return json.Unmarshal(request.Arguments, arguments)
+}
+
+// reads the content of a message framed by a Content-Length header
+func readFrame(reader *bufio.Reader) ([]byte, error) {
+	length := -1
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+
+		line = strings.TrimSpace(line)
+		if line == "" {
+			break
+		}
+
+		name, value, found := strings.Cut(line, ":")
+		if found && strings.EqualFold(strings.TrimSpace(name), "Content-Length") {
+			length, err = strconv.Atoi(strings.TrimSpace(value))
+			if err != nil || length < 0 {
+				return nil, fmt.Errorf("invalid content length '%s'", strings.TrimSpace(value))
+			}
+		}
+	}
+
+	if length < 0 {
+		return nil, fmt.Errorf("message is missing the Content-Length header")
+	}
+
+	content := make([]byte, length)
+	if _, err := io.ReadFull(reader, content); err != nil {
+		return nil, err
+	}
+
+	return content, nil
+}
@@ -0,0 +1,238 @@
+package debugging
+
+import (
+	"fmt"
+	"slices"
+	"sync"
+
+	"github.com/Drumstickz64/golox/ast"
+	"github.com/Drumstickz64/golox/interpreting"
+	"github.com/Drumstickz64/golox/parsing"
+)
+
+// how execution continues after the debugger stops
+type Command int
+
+const (
+	COMMAND_CONTINUE Command = iota
+	// stops at the next statement, entering calls
+	COMMAND_STEP_IN
+	// stops at the next statement in the current function, or in its caller
+	COMMAND_STEP_OVER
+	// stops at the next statement in the caller of the current function
+	COMMAND_STEP_OUT
+)
+
+const (
+	STOP_REASON_ENTRY      = "entry"
+	STOP_REASON_BREAKPOINT = "breakpoint"
+	STOP_REASON_STEP       = "step"
+	STOP_REASON_PAUSE      = "pause"
+)
+
+// returned by the interpreter when the frontend ends the debugging session before the program ends
+var ErrTerminated = fmt.Errorf("debugging session was terminated")
+
+type Stop struct {
+	// one of the STOP_REASON constants
+	Reason string
+	// source line of the statement about to be executed
+	Line int
+	// innermost first
+	Frames []interpreting.Frame
+}
+
+// Frontend lets the user inspect the program while it's stopped, and decides how it continues
+type Frontend interface {
+	// called on the interpreter's goroutine, which waits for it to return.
+	// Returning an error ends the program with that error
+	Stopped(debugger *Debugger, stop Stop) (Command, error)
+}
+
+// Debugger stops the program at breakpoints and after steps, and hands control to its frontend.
+// It can be attached to an interpreter with Interpreter.SetDebugger
+type Debugger struct {
+	frontend Frontend
+	// only statements with a span can be stopped at, which leaves out code desugared from for loops
+	spans    map[ast.Stmt]ast.Span
+	forLoops map[ast.Stmt]*ast.ForLoop
+
+	// guards everything below, since frontends can change breakpoints while the program runs
+	mu             sync.Mutex
+	breakpoints    map[int]bool
+	stopOnEntry    bool
+	pauseRequested bool
+	terminated     bool
+	command        Command
+	// call depth when the last command was given
+	commandDepth int
+	// position of the last statement that could be stopped at, so a line with several statements only stops once
+	// each time it's run. Reaching a statement that doesn't come after it, like the body of a loop again, starts a new run
+	lastLine   int
+	lastColumn int
+	lastDepth  int
+}
+
+// parser is the one that parsed the program, which knows where its statements are in the source
+func NewDebugger(frontend Frontend, parser parsing.Parser) *Debugger {
+	return &Debugger{
+		frontend:    frontend,
+		spans:       parser.Spans,
+		forLoops:    parser.ForLoops,
+		breakpoints: map[int]bool{},
+	}
+}
+
+// SetStopOnEntry makes the debugger stop before the first statement
+func (d *Debugger) SetStopOnEntry(stopOnEntry bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.stopOnEntry = stopOnEntry
+}
+
+// SetBreakpoints replaces every breakpoint with breakpoints on the given lines
+func (d *Debugger) SetBreakpoints(lines []int) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.breakpoints = map[int]bool{}
+	for _, line := range lines {
+		d.breakpoints[line] = true
+	}
+}
+
+func (d *Debugger) AddBreakpoint(line int) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.breakpoints[line] = true
+}
+
+// RemoveBreakpoint returns false if there was no breakpoint on line
+func (d *Debugger) RemoveBreakpoint(line int) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if !d.breakpoints[line] {
+		return false
+	}
+
+	delete(d.breakpoints, line)
+	return true
+}
+
+// Breakpoints returns the lines that have breakpoints, in order
+func (d *Debugger) Breakpoints() []int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	lines := make([]int, 0, len(d.breakpoints))
+	for line := range d.breakpoints {
+		lines = append(lines, line)
+	}
+
+	slices.Sort(lines)
+	return lines
+}
+
+// Pause stops the program before its next statement
+func (d *Debugger) Pause() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.pauseRequested = true
+}
+
+// Terminate stops the program with ErrTerminated before its next statement
+func (d *Debugger) Terminate() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.terminated = true
+}
+
+// Breakable reports whether the debugger can stop at stmt.
+// Blocks are skipped in favor of their statements, unless they were desugared from a for loop
+func (d *Debugger) Breakable(stmt ast.Stmt) bool {
+	if _, ok := stmt.(*ast.BlockStmt); ok && d.forLoops[stmt] == nil {
+		return false
+	}
+
+	_, ok := d.spans[stmt]
+	return ok
+}
+
+func (d *Debugger) BeforeStatement(interpreter *interpreting.Interpreter, stmt ast.Stmt) error {
+	if !d.Breakable(stmt) {
+		return nil
+	}
+
+	line := d.spans[stmt].Start.Line
+	column := d.spans[stmt].Start.Column
+	depth := interpreter.CallDepth()
+
+	d.mu.Lock()
+	if d.terminated {
+		d.mu.Unlock()
+		return ErrTerminated
+	}
+
+	reason := d.stopReason(line, column, depth)
+	d.lastLine = line
+	d.lastColumn = column
+	d.lastDepth = depth
+	d.mu.Unlock()
+
+	if reason == "" {
+		return nil
+	}
+
+	command, err := d.frontend.Stopped(d, Stop{
+		Reason: reason,
+		Line:   line,
+		Frames: interpreter.CallStack(),
+	})
+	if err != nil {
+		return err
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.command = command
+	d.commandDepth = depth
+	if d.terminated {
+		return ErrTerminated
+	}
+
+	return nil
+}
+
+// returns an empty reason if the debugger shouldn't stop
+func (d *Debugger) stopReason(line, column, depth int) string {
+	if d.stopOnEntry {
+		d.stopOnEntry = false
+		return STOP_REASON_ENTRY
+	}
+
+	if d.pauseRequested {
+		d.pauseRequested = false
+		return STOP_REASON_PAUSE
+	}
+
+	if line == d.lastLine && column > d.lastColumn && depth == d.lastDepth {
+		return ""
+	}
+
+	switch d.command {
+	case COMMAND_STEP_IN:
+		return STOP_REASON_STEP
+	case COMMAND_STEP_OVER:
+		if depth <= d.commandDepth {
+			return STOP_REASON_STEP
+		}
+	case COMMAND_STEP_OUT:
+		if depth < d.commandDepth {
+			return STOP_REASON_STEP
+		}
+	}
+
+	if d.breakpoints[line] {
+		return STOP_REASON_BREAKPOINT
+	}
+
+	return ""
+}
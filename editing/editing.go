@@ -0,0 +1,388 @@
+package editing
+
+import (
+	"bufio"
+	goerrors "errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"unicode"
+)
+
+// the number of history entries kept, older ones are forgotten
+const HISTORY_SIZE = 1000
+
+// returned by ReadLine when the user presses Ctrl-C
+var ErrInterrupted = goerrors.New("interrupted")
+
+const (
+	KEY_CTRL_A    = 1
+	KEY_CTRL_B    = 2
+	KEY_CTRL_C    = 3
+	KEY_CTRL_D    = 4
+	KEY_CTRL_E    = 5
+	KEY_CTRL_F    = 6
+	KEY_CTRL_H    = 8
+	KEY_TAB       = 9
+	KEY_LINE_FEED = 10
+	KEY_CTRL_K    = 11
+	KEY_CTRL_L    = 12
+	KEY_ENTER     = 13
+	KEY_CTRL_N    = 14
+	KEY_CTRL_P    = 16
+	KEY_CTRL_U    = 21
+	KEY_CTRL_W    = 23
+	KEY_ESCAPE    = 27
+	KEY_BACKSPACE = 127
+)
+
+// Editor reads lines from a terminal, letting the user move the cursor, go through the history and complete words.
+// When the input isn't a terminal, lines are read as they are
+type Editor struct {
+	in     *os.File
+	out    io.Writer
+	reader *bufio.Reader
+	// oldest first
+	history []string
+	// file the history is loaded from and saved to, empty when it isn't saved
+	historyPath string
+	// returns the candidates for completing word, which is the part of an identifier before the cursor
+	Complete func(word string) []string
+}
+
+func NewEditor(in *os.File, out io.Writer) *Editor {
+	return &Editor{
+		in:     in,
+		out:    out,
+		reader: bufio.NewReader(in),
+	}
+}
+
+// Input returns the file lines are read from and the reader buffering it,
+// which anything else reading the file has to read through, so it doesn't miss input the editor buffered
+func (e *Editor) Input() (*bufio.Reader, *os.File) {
+	return e.reader, e.in
+}
+
+// LoadHistory reads the history saved in the file at pth, and saves new entries to it.
+// A file that doesn't exist yet is an empty history
+func (e *Editor) LoadHistory(pth string) error {
+	e.historyPath = pth
+	contents, err := os.ReadFile(pth)
+	if goerrors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+
+	if err != nil {
+		return err
+	}
+
+	for _, line := range strings.Split(string(contents), "\n") {
+		if line != "" {
+			e.history = append(e.history, line)
+		}
+	}
+
+	e.history = e.history[max(len(e.history)-HISTORY_SIZE, 0):]
+	return nil
+}
+
+// AddHistory adds line to the history, and appends it to the history file if there is one.
+// Empty lines and repetitions of the previous entry are skipped
+func (e *Editor) AddHistory(line string) error {
+	if strings.TrimSpace(line) == "" || len(e.history) > 0 && e.history[len(e.history)-1] == line {
+		return nil
+	}
+
+	e.history = append(e.history, line)
+	if len(e.history) > HISTORY_SIZE {
+		e.history = e.history[1:]
+	}
+
+	if e.historyPath == "" {
+		return nil
+	}
+
+	file, err := os.OpenFile(e.historyPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintln(file, line)
+	return goerrors.Join(err, file.Close())
+}
+
+// ReadLine shows prompt and reads a line without its line ending. Returns io.EOF at the end of the input,
+// and ErrInterrupted if the user pressed Ctrl-C
+func (e *Editor) ReadLine(prompt string) (string, error) {
+	restore, err := makeRaw(e.in)
+	if err != nil {
+		return e.readPlainLine(prompt)
+	}
+	defer restore()
+
+	state := &lineState{editor: e, prompt: prompt, historyIndex: len(e.history)}
+	state.refresh()
+	for {
+		char, _, err := e.reader.ReadRune()
+		if err != nil {
+			return "", err
+		}
+
+		switch char {
+		case KEY_ENTER, KEY_LINE_FEED:
+			state.write("\r\n")
+			return string(state.line), nil
+		case KEY_CTRL_C:
+			state.write("^C\r\n")
+			return "", ErrInterrupted
+		case KEY_CTRL_D:
+			if len(state.line) == 0 {
+				state.write("\r\n")
+				return "", io.EOF
+			}
+			state.delete()
+		case KEY_BACKSPACE, KEY_CTRL_H:
+			state.backspace()
+		case KEY_TAB:
+			state.complete()
+		case KEY_CTRL_A:
+			state.cursor = 0
+		case KEY_CTRL_E:
+			state.cursor = len(state.line)
+		case KEY_CTRL_B:
+			state.cursor = max(state.cursor-1, 0)
+		case KEY_CTRL_F:
+			state.cursor = min(state.cursor+1, len(state.line))
+		case KEY_CTRL_P:
+			state.previousHistory()
+		case KEY_CTRL_N:
+			state.nextHistory()
+		case KEY_CTRL_K:
+			state.line = state.line[:state.cursor]
+		case KEY_CTRL_U:
+			state.line = state.line[state.cursor:]
+			state.cursor = 0
+		case KEY_CTRL_W:
+			state.deleteWord()
+		case KEY_CTRL_L:
+			state.write("\x1b[H\x1b[2J")
+		case KEY_ESCAPE:
+			if err := state.escapeSequence(); err != nil {
+				return "", err
+			}
+		default:
+			if unicode.IsPrint(char) {
+				state.insert([]rune{char})
+			}
+		}
+
+		state.refresh()
+	}
+}
+
+// reads a line from input that isn't a terminal, where the terminal already handles editing if there's one
+func (e *Editor) readPlainLine(prompt string) (string, error) {
+	fmt.Fprint(e.out, prompt)
+	line, err := e.reader.ReadString('\n')
+	if err != nil && (line == "" || !goerrors.Is(err, io.EOF)) {
+		return "", err
+	}
+
+	return strings.TrimSuffix(strings.TrimSuffix(line, "\n"), "\r"), nil
+}
+
+// the line being edited by ReadLine
+type lineState struct {
+	editor *Editor
+	prompt string
+	line   []rune
+	// index in line the cursor is before
+	cursor int
+	// index of the history entry being shown, len(history) for the line being written
+	historyIndex int
+	// the line being written, kept while going through the history
+	saved []rune
+}
+
+func (s *lineState) write(str string) {
+	io.WriteString(s.editor.out, str)
+}
+
+// redraws the prompt and line, and puts the cursor back in place
+func (s *lineState) refresh() {
+	redraw := "\r" + s.prompt + string(s.line) + "\x1b[K"
+	if back := len(s.line) - s.cursor; back > 0 {
+		redraw += fmt.Sprintf("\x1b[%dD", back)
+	}
+
+	s.write(redraw)
+}
+
+func (s *lineState) insert(chars []rune) {
+	line := make([]rune, 0, len(s.line)+len(chars))
+	line = append(line, s.line[:s.cursor]...)
+	line = append(line, chars...)
+	s.line = append(line, s.line[s.cursor:]...)
+	s.cursor += len(chars)
+}
+
+// deletes the character under the cursor
+func (s *lineState) delete() {
+	if s.cursor < len(s.line) {
+		s.line = append(s.line[:s.cursor], s.line[s.cursor+1:]...)
+	}
+}
+
+// deletes the character before the cursor
+func (s *lineState) backspace() {
+	if s.cursor > 0 {
+		s.cursor--
+		s.delete()
+	}
+}
+
+// deletes the word before the cursor, along with the spaces after it
+func (s *lineState) deleteWord() {
+	start := s.cursor
+	for start > 0 && unicode.IsSpace(s.line[start-1]) {
+		start--
+	}
+
+	for start > 0 && !unicode.IsSpace(s.line[start-1]) {
+		start--
+	}
+
+	s.line = append(s.line[:start], s.line[s.cursor:]...)
+	s.cursor = start
+}
+
+func (s *lineState) previousHistory() {
+	if s.historyIndex == 0 {
+		return
+	}
+
+	if s.historyIndex == len(s.editor.history) {
+		s.saved = s.line
+	}
+
+	s.historyIndex--
+	s.show([]rune(s.editor.history[s.historyIndex]))
+}
+
+func (s *lineState) nextHistory() {
+	if s.historyIndex == len(s.editor.history) {
+		return
+	}
+
+	s.historyIndex++
+	if s.historyIndex == len(s.editor.history) {
+		s.show(s.saved)
+	} else {
+		s.show([]rune(s.editor.history[s.historyIndex]))
+	}
+}
+
+func (s *lineState) show(line []rune) {
+	s.line = append([]rune{}, line...)
+	s.cursor = len(s.line)
+}
+
+// handles the keys sent as escape sequences, like the arrow keys
+func (s *lineState) escapeSequence() error {
+	kind, err := s.editor.reader.ReadByte()
+	if err != nil {
+		return err
+	}
+
+	if kind != '[' && kind != 'O' {
+		return nil
+	}
+
+	// parameters come before the final byte, like the 3 in "\x1b[3~" for the delete key
+	parameters := ""
+	for {
+		char, err := s.editor.reader.ReadByte()
+		if err != nil {
+			return err
+		}
+
+		if char >= 0x40 && char <= 0x7e {
+			s.escapeKey(char, parameters)
+			return nil
+		}
+
+		parameters += string(char)
+	}
+}
+
+func (s *lineState) escapeKey(final byte, parameters string) {
+	switch final {
+	case 'A':
+		s.previousHistory()
+	case 'B':
+		s.nextHistory()
+	case 'C':
+		s.cursor = min(s.cursor+1, len(s.line))
+	case 'D':
+		s.cursor = max(s.cursor-1, 0)
+	case 'H':
+		s.cursor = 0
+	case 'F':
+		s.cursor = len(s.line)
+	case '~':
+		switch parameters {
+		case "1", "7":
+			s.cursor = 0
+		case "4", "8":
+			s.cursor = len(s.line)
+		case "3":
+			s.delete()
+		}
+	}
+}
+
+// completes the word before the cursor, showing the candidates when there's more than one
+func (s *lineState) complete() {
+	if s.editor.Complete == nil {
+		return
+	}
+
+	start := s.cursor
+	for start > 0 && isWordChar(s.line[start-1]) {
+		start--
+	}
+
+	word := string(s.line[start:s.cursor])
+	candidates := s.editor.Complete(word)
+	if len(candidates) == 0 {
+		s.write("\a")
+		return
+	}
+
+	prefix := commonPrefix(candidates)
+	if len(prefix) > len(word) {
+		s.insert([]rune(prefix[len(word):]))
+		return
+	}
+
+	if len(candidates) > 1 {
+		s.write("\r\n" + strings.Join(candidates, "  ") + "\r\n")
+	}
+}
+
+func isWordChar(char rune) bool {
+	return char == '_' || unicode.IsLetter(char) || unicode.IsDigit(char)
+}
+
+func commonPrefix(words []string) string {
+	prefix := words[0]
+	for _, word := range words[1:] {
+		for !strings.HasPrefix(word, prefix) {
+			prefix = prefix[:len(prefix)-1]
+		}
+	}
+
+	return prefix
+}
@@ -0,0 +1,8 @@
+package editing
+
+import "syscall"
+
+const (
+	IOCTL_GET_TERMIOS = syscall.TIOCGETA
+	IOCTL_SET_TERMIOS = syscall.TIOCSETA
+)
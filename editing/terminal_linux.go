@@ -0,0 +1,8 @@
+package editing
+
+import "syscall"
+
+const (
+	IOCTL_GET_TERMIOS = syscall.TCGETS
+	IOCTL_SET_TERMIOS = syscall.TCSETS
+)
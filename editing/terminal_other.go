@@ -0,0 +1,13 @@
+//go:build !linux && !darwin
+
+package editing
+
+import (
+	goerrors "errors"
+	"os"
+)
+
+// raw mode isn't supported on this platform, so lines are always read as they are
+func makeRaw(file *os.File) (restore func(), err error) {
+	return nil, goerrors.New("raw mode isn't supported on this platform")
+}
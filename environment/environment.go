@@ -2,6 +2,7 @@ package environment
 
 import (
 	"fmt"
+	"slices"
 
 	"github.com/Drumstickz64/golox/assert"
 	"github.com/Drumstickz64/golox/errors"
@@ -54,6 +55,29 @@ func (e *Environment) GetAt(distance int, name string) any {
 	return value
 }
 
+// IsDefined reports whether name is defined in this environment, ignoring enclosing environments
+func (e *Environment) IsDefined(name string) bool {
+	_, ok := e.values[name]
+	return ok
+}
+
+// Names returns the names defined in this environment, ignoring enclosing environments
+func (e *Environment) Names() []string {
+	names := make([]string, 0, len(e.values))
+	for name := range e.values {
+		names = append(names, name)
+	}
+
+	slices.Sort(names)
+	return names
+}
+
+// Value returns the value of name in this environment, ignoring enclosing environments
+func (e *Environment) Value(name string) (any, bool) {
+	value, ok := e.values[name]
+	return value, ok
+}
+
 func (e *Environment) Define(name string, value any) {
 	e.values[name] = value
 }
@@ -3,7 +3,9 @@ package errors
 import (
 	"fmt"
 	"os"
+	"runtime/debug"
 
+	"github.com/Drumstickz64/golox/assert"
 	"github.com/Drumstickz64/golox/token"
 )
 
@@ -12,15 +14,65 @@ func LogCliError(msg any, exitCode int) {
 	os.Exit(exitCode)
 }
 
-func LogUsageMessage() {
-	fmt.Fprintln(os.Stderr, "Usage: golox [script [scan|parse|run]]")
-	os.Exit(64)
+// an error found while scanning, parsing or resolving, before the program is run
+type BuildtimeError struct {
+	Line, Column int
+	Where        string
+	Msg          any
+}
+
+func (e *BuildtimeError) Error() string {
+	return fmt.Sprintf("[line %d:%d] Error%v: %v", e.Line, e.Column, e.Where, e.Msg)
 }
 
 func NewBuildtimeError(line, column int, where string, msg any) error {
-	return fmt.Errorf("[line %d:%d] Error%v: %v", line, column, where, msg)
+	return &BuildtimeError{
+		Line:   line,
+		Column: column,
+		Where:  where,
+		Msg:    msg,
+	}
 }
 
 func NewRuntimeError(tok token.Token, msg any) error {
 	return fmt.Errorf("encountered a runtime error: %v\n[on %d:%d]", msg, tok.Line, tok.Column)
 }
+
+// an error caused by a bug in golox rather than in the script, like a failed assertion
+type InternalError struct {
+	Msg any
+	// where in the source it happened, Line is 0 when that isn't known
+	Line, Column int
+	// the Go stack at the time of the failure, to include in bug reports
+	Stack []byte
+}
+
+func (e *InternalError) Error() string {
+	if e.Line == 0 {
+		return fmt.Sprintf("encountered an internal error: %v", e.Msg)
+	}
+
+	return fmt.Sprintf("encountered an internal error: %v\n[on %d:%d]", e.Msg, e.Line, e.Column)
+}
+
+// RecoverAssertion has to be deferred. It recovers from a failed assertion, passing it to report as an InternalError
+// at the position returned by where. Other panics keep going, since they aren't known to leave the program in a state
+// it can go on from, and so do failed assertions when they're strict
+func RecoverAssertion(where func() (int, int), report func(err *InternalError)) {
+	if assert.IsStrict() {
+		return
+	}
+
+	recovered := recover()
+	if recovered == nil {
+		return
+	}
+
+	failure, ok := recovered.(*assert.Failure)
+	if !ok {
+		panic(recovered)
+	}
+
+	line, column := where()
+	report(&InternalError{Msg: failure.Msg, Line: line, Column: column, Stack: debug.Stack()})
+}
@@ -0,0 +1,307 @@
+package formatting
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/Drumstickz64/golox/assert"
+	"github.com/Drumstickz64/golox/ast"
+	"github.com/Drumstickz64/golox/parsing"
+	"github.com/Drumstickz64/golox/scanning"
+	"github.com/Drumstickz64/golox/token"
+)
+
+const indentation = "  "
+
+// Format reformats Lox source code, keeping its comments.
+// Returns the errors found while scanning and parsing if the source is invalid
+func Format(source string) (string, []error) {
+	scanner := scanning.NewScanner(source)
+	tokens, errs := scanner.ScanTokens()
+	if len(errs) > 0 {
+		return "", errs
+	}
+
+	parser := parsing.NewParser(tokens)
+	statements, errs := parser.Parse()
+	if len(errs) > 0 {
+		return "", errs
+	}
+
+	if parser.HadError {
+		return "", []error{fmt.Errorf("failed to parse source")}
+	}
+
+	formatter := &formatter{
+		comments: scanner.Comments(),
+		spans:    parser.Spans,
+		forLoops: parser.ForLoops,
+		isFirst:  true,
+	}
+	formatter.writeStmts(statements, tokens[len(tokens)-1])
+	formatter.builder.WriteString("\n")
+
+	return formatter.builder.String(), nil
+}
+
+type formatter struct {
+	// comments that haven't been written yet
+	comments []token.Token
+	spans    map[ast.Stmt]ast.Span
+	forLoops map[ast.Stmt]*ast.ForLoop
+	builder  strings.Builder
+	depth    int
+	// the source line of the last statement or comment that was written
+	lastLine int
+	// whether nothing was written yet in the current block, blank lines are never added at the start of a block
+	isFirst bool
+}
+
+// writes each statement on its own line, followed by the comments that come before the closing token
+func (f *formatter) writeStmts(stmts []ast.Stmt, closing token.Token) {
+	for _, stmt := range stmts {
+		span, hasSpan := f.spans[stmt]
+		if hasSpan {
+			f.writeCommentsBefore(span.Start)
+			f.startElement(span.Start.Line)
+		} else {
+			f.startElement(0)
+		}
+
+		f.writeStmt(stmt)
+
+		if hasSpan {
+			f.lastLine = span.End.Line
+			f.writeTrailingComments(span.End.Line)
+		}
+	}
+
+	f.writeCommentsBefore(closing)
+}
+
+// starts a new line for a statement or comment found at line in the source
+func (f *formatter) startElement(line int) {
+	// consecutive blank lines in the source are collapsed into a single blank line
+	if !f.isFirst && line > 0 && f.lastLine > 0 && line > f.lastLine+1 {
+		f.builder.WriteString("\n")
+	}
+
+	if f.builder.Len() > 0 {
+		f.newline()
+	}
+
+	f.isFirst = false
+}
+
+func (f *formatter) newline() {
+	f.builder.WriteString("\n")
+	f.builder.WriteString(strings.Repeat(indentation, f.depth))
+}
+
+func (f *formatter) writeCommentsBefore(tok token.Token) {
+	for len(f.comments) > 0 && isBefore(f.comments[0], tok) {
+		comment := f.comments[0]
+		f.comments = f.comments[1:]
+
+		f.startElement(comment.Line)
+		f.builder.WriteString(comment.Lexeme)
+		f.lastLine = comment.Line + strings.Count(comment.Lexeme, "\n")
+	}
+}
+
+// writes the comments that start on the same line as the end of a statement
+func (f *formatter) writeTrailingComments(line int) {
+	for len(f.comments) > 0 && f.comments[0].Line == line {
+		comment := f.comments[0]
+		f.comments = f.comments[1:]
+
+		f.builder.WriteString(" " + comment.Lexeme)
+		f.lastLine = comment.Line + strings.Count(comment.Lexeme, "\n")
+	}
+}
+
+func (f *formatter) writeStmt(stmt ast.Stmt) {
+	if loop, ok := f.forLoops[stmt]; ok {
+		f.writeFor(loop)
+		return
+	}
+
+	switch stmt := stmt.(type) {
+	case *ast.BlockStmt:
+		f.writeBlock(stmt, stmt.Statements)
+	case *ast.ClassStmt:
+		f.builder.WriteString("class " + stmt.Name.Lexeme)
+		if stmt.SuperClass != nil {
+			f.builder.WriteString(" < " + stmt.SuperClass.Name.Lexeme)
+		}
+		f.builder.WriteString(" ")
+
+		methods := make([]ast.Stmt, 0, len(stmt.Methods))
+		for _, method := range stmt.Methods {
+			methods = append(methods, method)
+		}
+		f.writeBlock(stmt, methods)
+	case *ast.ExpressionStmt:
+		f.builder.WriteString(exprString(stmt.Expression) + ";")
+	case *ast.WhileStmt:
+		f.builder.WriteString("while (" + exprString(stmt.Condition) + ")")
+		f.writeBody(stmt.Body)
+	case *ast.IfStmt:
+		f.builder.WriteString("if (" + exprString(stmt.Condition) + ")")
+		isBlock := f.writeBody(stmt.ThenBranch)
+		if stmt.ElseBranch == nil {
+			return
+		}
+
+		if isBlock {
+			f.builder.WriteString(" ")
+		} else {
+			f.newline()
+		}
+		f.builder.WriteString("else")
+		f.writeBody(stmt.ElseBranch)
+	case *ast.PrintStmt:
+		f.builder.WriteString("print " + exprString(stmt.Expression) + ";")
+	case *ast.ReturnStmt:
+		if stmt.Value == nil {
+			f.builder.WriteString("return;")
+		} else {
+			f.builder.WriteString("return " + exprString(stmt.Value) + ";")
+		}
+	case *ast.VarStmt:
+		if stmt.Initializer == nil {
+			f.builder.WriteString("var " + stmt.Name.Lexeme + ";")
+		} else {
+			f.builder.WriteString("var " + stmt.Name.Lexeme + " = " + exprString(stmt.Initializer) + ";")
+		}
+	case *ast.FunctionStmt:
+		// methods are declared without the keyword
+		if f.spans[stmt].Start.Kind == token.FUN {
+			f.builder.WriteString("fun ")
+		}
+
+		parameters := make([]string, 0, len(stmt.Parameters))
+		for _, parameter := range stmt.Parameters {
+			parameters = append(parameters, parameter.Lexeme)
+		}
+
+		f.builder.WriteString(stmt.Name.Lexeme + "(" + strings.Join(parameters, ", ") + ") ")
+		f.writeBlock(stmt, stmt.Body)
+	}
+}
+
+func (f *formatter) writeFor(loop *ast.ForLoop) {
+	f.builder.WriteString("for (")
+	if loop.Initializer == nil {
+		f.builder.WriteString(";")
+	} else {
+		f.writeStmt(loop.Initializer)
+	}
+
+	if loop.Condition != nil {
+		f.builder.WriteString(" " + exprString(loop.Condition))
+	}
+	f.builder.WriteString(";")
+
+	if loop.Increment != nil {
+		f.builder.WriteString(" " + exprString(loop.Increment))
+	}
+	f.builder.WriteString(")")
+
+	f.writeBody(loop.Body)
+}
+
+// writes the body of a control flow statement, blocks start on the same line.
+// Returns whether the body is a block
+func (f *formatter) writeBody(body ast.Stmt) bool {
+	f.builder.WriteString(" ")
+
+	block, isBlock := body.(*ast.BlockStmt)
+	if _, isFor := f.forLoops[body]; !isBlock || isFor {
+		f.writeStmt(body)
+		return false
+	}
+
+	f.writeBlock(block, block.Statements)
+	return true
+}
+
+// writes statements surrounded by braces, the closing brace is the end of the owner's span
+func (f *formatter) writeBlock(owner ast.Stmt, stmts []ast.Stmt) {
+	closing := f.spans[owner].End
+	f.builder.WriteString("{")
+	if len(stmts) == 0 && (len(f.comments) == 0 || !isBefore(f.comments[0], closing)) {
+		f.builder.WriteString("}")
+		return
+	}
+
+	f.depth++
+	f.isFirst = true
+	f.writeStmts(stmts, closing)
+	f.depth--
+
+	f.newline()
+	f.builder.WriteString("}")
+	f.lastLine = closing.Line
+}
+
+// whether comment starts before tok, tokens that weren't found in the source are after every comment
+func isBefore(comment, tok token.Token) bool {
+	if tok.Line == 0 || tok.Kind == token.EOF {
+		return true
+	}
+
+	if comment.Line != tok.Line {
+		return comment.Line < tok.Line
+	}
+
+	// token columns are where the token ends
+	return comment.Column <= tok.Column-len(tok.Lexeme)
+}
+
+func exprString(expr ast.Expr) string {
+	switch expr := expr.(type) {
+	case *ast.BinaryExpr:
+		return exprString(expr.Left) + " " + expr.Operator.Lexeme + " " + exprString(expr.Right)
+	case *ast.LogicalExpr:
+		return exprString(expr.Left) + " " + expr.Operator.Lexeme + " " + exprString(expr.Right)
+	case *ast.GroupingExpr:
+		return "(" + exprString(expr.Expression) + ")"
+	case *ast.LiteralExpr:
+		switch value := expr.Value.(type) {
+		case nil:
+			return "nil"
+		case string:
+			return "\"" + value + "\""
+		case float64:
+			return strconv.FormatFloat(value, 'f', -1, 64)
+		default:
+			return fmt.Sprint(value)
+		}
+	case *ast.UnaryExpr:
+		return expr.Operator.Lexeme + exprString(expr.Right)
+	case *ast.CallExpr:
+		arguments := make([]string, 0, len(expr.Arguments))
+		for _, argument := range expr.Arguments {
+			arguments = append(arguments, exprString(argument))
+		}
+
+		return exprString(expr.Callee) + "(" + strings.Join(arguments, ", ") + ")"
+	case *ast.GetExpr:
+		return exprString(expr.Object) + "." + expr.Name.Lexeme
+	case *ast.SetExpr:
+		return exprString(expr.Object) + "." + expr.Name.Lexeme + " = " + exprString(expr.Value)
+	case *ast.SuperExpr:
+		return "super." + expr.Method.Lexeme
+	case *ast.ThisExpr:
+		return "this"
+	case *ast.VariableExpr:
+		return expr.Name.Lexeme
+	case *ast.AssignmentExpr:
+		return expr.Name.Lexeme + " = " + exprString(expr.Value)
+	}
+
+	assert.Unreachable(fmt.Sprintf("'%T' is a valid expression", expr))
+	return ""
+}
@@ -0,0 +1,75 @@
+package interpreting
+
+import (
+	"fmt"
+
+	"github.com/Drumstickz64/golox/errors"
+	"github.com/Drumstickz64/golox/token"
+)
+
+// returned by the assertion natives when an assertion doesn't hold
+type AssertionError struct {
+	// closing parenthesis of the failed assertion's call
+	Token token.Token
+	Msg   string
+}
+
+func (e *AssertionError) Error() string {
+	return errors.NewRuntimeError(e.Token, "assertion failed: "+e.Msg).Error()
+}
+
+// EnableAssertions defines the 'assert', 'assertEqual' and 'assertThrows' natives used by tests
+func (i *Interpreter) EnableAssertions() {
+	i.globals.Define("assert", &nativeFunction{
+		arity: 2,
+		call: func(interpreter *Interpreter, paren token.Token, arguments []any) (any, error) {
+			if isTruthy(arguments[0]) {
+				return nil, nil
+			}
+
+			return nil, &AssertionError{Token: paren, Msg: stringify(arguments[1])}
+		},
+	})
+
+	i.globals.Define("assertEqual", &nativeFunction{
+		arity: 2,
+		call: func(interpreter *Interpreter, paren token.Token, arguments []any) (any, error) {
+			actual, expected := arguments[0], arguments[1]
+			if actual == expected {
+				return nil, nil
+			}
+
+			return nil, &AssertionError{Token: paren, Msg: fmt.Sprintf("expected %s but got %s", quote(expected), quote(actual))}
+		},
+	})
+
+	i.globals.Define("assertThrows", &nativeFunction{
+		arity: 1,
+		call: func(interpreter *Interpreter, paren token.Token, arguments []any) (any, error) {
+			callable, ok := arguments[0].(Callable)
+			if !ok || callable.Arity() != 0 {
+				return nil, errors.NewRuntimeError(paren, "argument 1 to 'assertThrows' must be a function without parameters")
+			}
+
+			_, err := interpreter.call(callable, paren, nil)
+			if isFatal(err) {
+				return nil, err
+			}
+
+			if err == nil {
+				return nil, &AssertionError{Token: paren, Msg: fmt.Sprintf("expected %s to throw a runtime error", stringify(callable))}
+			}
+
+			return nil, nil
+		},
+	})
+}
+
+// stringifies value, quoting strings so they can be told apart from other values
+func quote(value any) string {
+	if str, ok := value.(string); ok {
+		return fmt.Sprintf("%q", str)
+	}
+
+	return stringify(value)
+}
@@ -6,11 +6,13 @@ import (
 	"github.com/Drumstickz64/golox/assert"
 	"github.com/Drumstickz64/golox/ast"
 	"github.com/Drumstickz64/golox/environment"
+	"github.com/Drumstickz64/golox/token"
 )
 
 type Callable interface {
 	Arity() int
-	Call(interpreter *Interpreter, arguments []any) (any, error)
+	// paren is the closing parenthesis of the call expression, used for reporting runtime errors
+	Call(interpreter *Interpreter, paren token.Token, arguments []any) (any, error)
 }
 
 type function struct {
@@ -23,11 +25,27 @@ func (f *function) Arity() int {
 	return len(f.declaration.Parameters)
 }
 
-func (f *function) Call(interpreter *Interpreter, arguments []any) (any, error) {
+func (f *function) Call(interpreter *Interpreter, paren token.Token, arguments []any) (any, error) {
 	assert.Eq(len(arguments), f.Arity())
 
 	defer func() { interpreter.isReturning = false }()
 
+	if interpreter.ctx != nil {
+		if err := interpreter.checkContext(paren); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := interpreter.enterCall(paren); err != nil {
+		return nil, err
+	}
+	defer interpreter.exitCall()
+
+	if interpreter.debugger != nil {
+		interpreter.enterFrame(f.declaration.Name.Lexeme, paren)
+		defer interpreter.exitFrame()
+	}
+
 	env := environment.WithEnclosing(f.closure)
 	for i, param := range f.declaration.Parameters {
 		env.Define(param.Lexeme, arguments[i])
@@ -59,16 +77,16 @@ func (f *function) bind(this *Instance) *function {
 
 type nativeFunction struct {
 	arity int
-	call  func(interpreter *Interpreter, arguments []any) (any, error)
+	call  func(interpreter *Interpreter, paren token.Token, arguments []any) (any, error)
 }
 
 func (f *nativeFunction) Arity() int {
 	return f.arity
 }
 
-func (f *nativeFunction) Call(interpreter *Interpreter, arguments []any) (any, error) {
+func (f *nativeFunction) Call(interpreter *Interpreter, paren token.Token, arguments []any) (any, error) {
 	assert.Eq(len(arguments), f.Arity())
-	return f.call(interpreter, arguments)
+	return f.call(interpreter, paren, arguments)
 }
 
 func (f *nativeFunction) String() string {
@@ -13,12 +13,14 @@ type class struct {
 	methods    map[string]*function
 }
 
-func (c *class) Call(interpreter *Interpreter, arguments []any) (any, error) {
+func (c *class) Call(interpreter *Interpreter, paren token.Token, arguments []any) (any, error) {
 	ins := NewInstance(c)
 
 	initializer, ok := c.findMethod("init")
 	if ok {
-		initializer.bind(ins).Call(interpreter, arguments)
+		if _, err := initializer.bind(ins).Call(interpreter, paren, arguments); err != nil {
+			return nil, err
+		}
 	}
 
 	return ins, nil
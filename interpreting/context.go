@@ -0,0 +1,111 @@
+package interpreting
+
+import (
+	"context"
+	goerrors "errors"
+
+	"github.com/Drumstickz64/golox/ast"
+	"github.com/Drumstickz64/golox/errors"
+	"github.com/Drumstickz64/golox/token"
+)
+
+// returned when the context passed to InterpretContext is canceled while the script runs.
+// Like limits, it can't be caught by scripts
+type CanceledError struct {
+	// where the script was stopped, has no position when that isn't known
+	Token token.Token
+}
+
+func (e *CanceledError) Error() string {
+	return runtimeErrorAt(e.Token, "the script was canceled")
+}
+
+func (e *CanceledError) Unwrap() error {
+	return context.Canceled
+}
+
+// InterpretContext is like Interpret, but stops once ctx is done. The context is checked at every loop iteration
+// and call, and blocking natives like 'time.sleep' return early. A passed deadline is reported as a LimitError,
+// and cancellation as a CanceledError
+func (i *Interpreter) InterpretContext(ctx context.Context, statements []ast.Stmt) error {
+	defer i.useContext(ctx)()
+	return i.Interpret(statements)
+}
+
+// EvaluateContext is like Evaluate, but stops once ctx is done, see InterpretContext
+func (i *Interpreter) EvaluateContext(ctx context.Context, expr ast.Expr) (any, error) {
+	defer i.useContext(ctx)()
+	return i.Evaluate(expr)
+}
+
+// makes the interpreter check ctx, and returns a function that goes back to the previous context
+func (i *Interpreter) useContext(ctx context.Context) func() {
+	previous := i.ctx
+	// contexts that can never be done, like context.Background, cost nothing to check
+	if ctx.Done() != nil {
+		i.ctx = ctx
+	}
+
+	return func() { i.ctx = previous }
+}
+
+// returns the error to stop the script with if the context is done, tok is where it's checked
+func (i *Interpreter) checkContext(tok token.Token) error {
+	if i.ctx == nil {
+		return nil
+	}
+
+	err := i.ctx.Err()
+	if goerrors.Is(err, context.DeadlineExceeded) {
+		return &LimitError{Limit: LIMIT_TIME, Token: tok}
+	}
+
+	if err != nil {
+		return &CanceledError{Token: tok}
+	}
+
+	return nil
+}
+
+// runs the blocking operation of a native, returning as soon as the context is done.
+// The operation keeps running in the background then, and its result is dropped
+func (i *Interpreter) blocking(paren token.Token, operation func() (any, error)) (any, error) {
+	if i.ctx == nil {
+		return operation()
+	}
+
+	type outcome struct {
+		value any
+		err   error
+	}
+
+	done := make(chan outcome, 1)
+	go func() {
+		value, err := operation()
+		done <- outcome{value, err}
+	}()
+
+	select {
+	case result := <-done:
+		return result.value, result.err
+	case <-i.ctx.Done():
+		return nil, i.checkContext(paren)
+	}
+}
+
+// errors that stop the script, which it can't recover from
+func isFatal(err error) bool {
+	var exitErr *ExitError
+	var limitErr *LimitError
+	var canceledErr *CanceledError
+	return goerrors.As(err, &exitErr) || goerrors.As(err, &limitErr) || goerrors.As(err, &canceledErr)
+}
+
+// formats a runtime error like errors.NewRuntimeError, leaving out the position when tok has none
+func runtimeErrorAt(tok token.Token, msg string) string {
+	if tok.Line == 0 {
+		return "encountered a runtime error: " + msg
+	}
+
+	return errors.NewRuntimeError(tok, msg).Error()
+}
@@ -0,0 +1,76 @@
+package interpreting
+
+import (
+	"slices"
+
+	"github.com/Drumstickz64/golox/ast"
+	"github.com/Drumstickz64/golox/environment"
+	"github.com/Drumstickz64/golox/token"
+)
+
+// Debugger is notified before every statement is executed. The interpreter waits for it to return,
+// which is how a debugger pauses execution. Returning an error stops the program with that error
+type Debugger interface {
+	BeforeStatement(interpreter *Interpreter, stmt ast.Stmt) error
+}
+
+// a function call being executed, only tracked while a debugger is attached
+type Frame struct {
+	// name of the called function, or "script" for top-level code
+	Name string
+	// closing parenthesis of the call, has no position for top-level code
+	Call token.Token
+	// statement currently being executed in this frame
+	Stmt ast.Stmt
+	// innermost scope of this frame
+	Env *environment.Environment
+}
+
+// SetDebugger attaches a debugger, or detaches it when debugger is nil.
+// Without a debugger attached, the interpreter only pays for a nil check per statement
+func (i *Interpreter) SetDebugger(debugger Debugger) {
+	i.debugger = debugger
+	i.frames = nil
+	if debugger != nil {
+		i.frames = []Frame{{Name: "script"}}
+	}
+}
+
+// CallStack returns the frames of the calls being executed, innermost first.
+// Only available while a debugger is attached
+func (i *Interpreter) CallStack() []Frame {
+	if len(i.frames) == 0 {
+		return nil
+	}
+
+	frames := slices.Clone(i.frames)
+	frames[len(frames)-1].Env = i.env
+	slices.Reverse(frames)
+	return frames
+}
+
+// Globals returns the scope holding the global variables
+func (i *Interpreter) Globals() *environment.Environment {
+	return i.globals
+}
+
+func (i *Interpreter) debugStatement(stmt ast.Stmt) error {
+	i.frames[len(i.frames)-1].Stmt = stmt
+	return i.debugger.BeforeStatement(i, stmt)
+}
+
+func (i *Interpreter) enterFrame(name string, call token.Token) {
+	// the caller's scope is only known while it's the current one
+	i.frames[len(i.frames)-1].Env = i.env
+	i.frames = append(i.frames, Frame{Name: name, Call: call})
+}
+
+func (i *Interpreter) exitFrame() {
+	i.frames = i.frames[:len(i.frames)-1]
+}
+
+// CallDepth returns the number of calls being executed, counting top-level code as one.
+// Only available while a debugger is attached
+func (i *Interpreter) CallDepth() int {
+	return len(i.frames)
+}
@@ -0,0 +1,223 @@
+package interpreting
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/Drumstickz64/golox/environment"
+	"github.com/Drumstickz64/golox/errors"
+	"github.com/Drumstickz64/golox/token"
+)
+
+func defineFsNatives(globals *environment.Environment) {
+	globals.Define("readFile", &nativeFunction{
+		arity: 1,
+		call: func(interpreter *Interpreter, paren token.Token, arguments []any) (any, error) {
+			path, err := interpreter.pathArgument(paren, "readFile", PERMISSION_READ, arguments, 0)
+			if err != nil {
+				return nil, err
+			}
+
+			return interpreter.blocking(paren, func() (any, error) {
+				content, err := os.ReadFile(path)
+				if err != nil {
+					return nil, errors.NewRuntimeError(paren, fmt.Sprintf("failed to read file: %v", err))
+				}
+
+				return string(content), nil
+			})
+		},
+	})
+
+	globals.Define("readLines", &nativeFunction{
+		arity: 1,
+		call: func(interpreter *Interpreter, paren token.Token, arguments []any) (any, error) {
+			path, err := interpreter.pathArgument(paren, "readLines", PERMISSION_READ, arguments, 0)
+			if err != nil {
+				return nil, err
+			}
+
+			return interpreter.blocking(paren, func() (any, error) {
+				file, err := os.Open(path)
+				if err != nil {
+					return nil, errors.NewRuntimeError(paren, fmt.Sprintf("failed to read file: %v", err))
+				}
+				defer file.Close()
+
+				lines := []any{}
+				scanner := bufio.NewScanner(file)
+				for scanner.Scan() {
+					lines = append(lines, scanner.Text())
+				}
+
+				if err := scanner.Err(); err != nil {
+					return nil, errors.NewRuntimeError(paren, fmt.Sprintf("failed to read file: %v", err))
+				}
+
+				return newList(lines), nil
+			})
+		},
+	})
+
+	globals.Define("writeFile", &nativeFunction{
+		arity: 2,
+		call: func(interpreter *Interpreter, paren token.Token, arguments []any) (any, error) {
+			path, err := interpreter.pathArgument(paren, "writeFile", PERMISSION_WRITE, arguments, 0)
+			if err != nil {
+				return nil, err
+			}
+
+			content, err := stringArgument(paren, "writeFile", arguments, 1)
+			if err != nil {
+				return nil, err
+			}
+
+			return interpreter.blocking(paren, func() (any, error) {
+				if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+					return nil, errors.NewRuntimeError(paren, fmt.Sprintf("failed to write file: %v", err))
+				}
+
+				return nil, nil
+			})
+		},
+	})
+
+	globals.Define("appendFile", &nativeFunction{
+		arity: 2,
+		call: func(interpreter *Interpreter, paren token.Token, arguments []any) (any, error) {
+			path, err := interpreter.pathArgument(paren, "appendFile", PERMISSION_WRITE, arguments, 0)
+			if err != nil {
+				return nil, err
+			}
+
+			content, err := stringArgument(paren, "appendFile", arguments, 1)
+			if err != nil {
+				return nil, err
+			}
+
+			return interpreter.blocking(paren, func() (any, error) {
+				file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+				if err != nil {
+					return nil, errors.NewRuntimeError(paren, fmt.Sprintf("failed to append to file: %v", err))
+				}
+				defer file.Close()
+
+				if _, err := file.WriteString(content); err != nil {
+					return nil, errors.NewRuntimeError(paren, fmt.Sprintf("failed to append to file: %v", err))
+				}
+
+				return nil, nil
+			})
+		},
+	})
+
+	globals.Define("exists", &nativeFunction{
+		arity: 1,
+		call: func(interpreter *Interpreter, paren token.Token, arguments []any) (any, error) {
+			path, err := interpreter.pathArgument(paren, "exists", PERMISSION_READ, arguments, 0)
+			if err != nil {
+				return nil, err
+			}
+
+			_, err = os.Stat(path)
+			return err == nil, nil
+		},
+	})
+
+	globals.Define("listDir", &nativeFunction{
+		arity: 1,
+		call: func(interpreter *Interpreter, paren token.Token, arguments []any) (any, error) {
+			path, err := interpreter.pathArgument(paren, "listDir", PERMISSION_READ, arguments, 0)
+			if err != nil {
+				return nil, err
+			}
+
+			return interpreter.blocking(paren, func() (any, error) {
+				entries, err := os.ReadDir(path)
+				if err != nil {
+					return nil, errors.NewRuntimeError(paren, fmt.Sprintf("failed to list directory: %v", err))
+				}
+
+				names := make([]any, 0, len(entries))
+				for _, entry := range entries {
+					names = append(names, entry.Name())
+				}
+
+				return newList(names), nil
+			})
+		},
+	})
+
+	globals.Define("mkdir", &nativeFunction{
+		arity: 1,
+		call: func(interpreter *Interpreter, paren token.Token, arguments []any) (any, error) {
+			path, err := interpreter.pathArgument(paren, "mkdir", PERMISSION_WRITE, arguments, 0)
+			if err != nil {
+				return nil, err
+			}
+
+			return interpreter.blocking(paren, func() (any, error) {
+				if err := os.MkdirAll(path, 0755); err != nil {
+					return nil, errors.NewRuntimeError(paren, fmt.Sprintf("failed to create directory: %v", err))
+				}
+
+				return nil, nil
+			})
+		},
+	})
+
+	globals.Define("remove", &nativeFunction{
+		arity: 1,
+		call: func(interpreter *Interpreter, paren token.Token, arguments []any) (any, error) {
+			path, err := interpreter.pathArgument(paren, "remove", PERMISSION_WRITE, arguments, 0)
+			if err != nil {
+				return nil, err
+			}
+
+			return interpreter.blocking(paren, func() (any, error) {
+				if err := os.Remove(path); err != nil {
+					return nil, errors.NewRuntimeError(paren, fmt.Sprintf("failed to remove: %v", err))
+				}
+
+				return nil, nil
+			})
+		},
+	})
+}
+
+// reads a path argument, resolves it against the interpreter's root directory
+// and checks that the interpreter has the permission to access it
+func (i *Interpreter) pathArgument(paren token.Token, native string, permission Permission, arguments []any, index int) (string, error) {
+	path, err := stringArgument(paren, native, arguments, index)
+	if err != nil {
+		return "", err
+	}
+
+	if i.root == "" && i.permissions.grantsAll(permission) {
+		return path, nil
+	}
+
+	// the path isn't cleaned before it's joined, since that's left to realPath
+	resolved := path
+	if i.root != "" && !filepath.IsAbs(path) {
+		resolved = i.root + string(filepath.Separator) + path
+	}
+
+	// the path that's checked is the one that's used, so links can't lead outside of the root or the allowed directories
+	real, err := realPath(resolved)
+	if err != nil {
+		return "", errors.NewRuntimeError(paren, fmt.Sprintf("failed to resolve path '%s': %v", path, err))
+	}
+
+	if i.root != "" && !isInside(i.root, real) {
+		return "", errors.NewRuntimeError(paren, fmt.Sprintf("path '%s' is outside of the root directory", path))
+	}
+
+	if err := i.checkPermission(paren, native, permission, real); err != nil {
+		return "", err
+	}
+
+	return real, nil
+}
@@ -0,0 +1,7 @@
+package interpreting
+
+import "syscall"
+
+func selectRead(nfd int, set *syscall.FdSet, timeout *syscall.Timeval) error {
+	return syscall.Select(nfd, set, nil, nil, timeout)
+}
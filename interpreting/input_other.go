@@ -0,0 +1,13 @@
+//go:build !linux && !darwin
+
+package interpreting
+
+import (
+	"context"
+	"os"
+)
+
+// waiting for input can't be interrupted on this platform, so reads block until there is some
+func waitReadable(ctx context.Context, file *os.File) error {
+	return nil
+}
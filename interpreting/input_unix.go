@@ -0,0 +1,49 @@
+//go:build linux || darwin
+
+package interpreting
+
+import (
+	"context"
+	goerrors "errors"
+	"os"
+	"syscall"
+	"time"
+	"unsafe"
+)
+
+// how often waitReadable checks the context while there's no input
+const INPUT_POLL_INTERVAL = 50 * time.Millisecond
+
+// waits until file has input to read, or until ctx is done, returning the context's error then.
+// Files that can't be waited on, like ones with descriptors too large for select, are treated as readable
+func waitReadable(ctx context.Context, file *os.File) error {
+	fd := int(file.Fd())
+	set := &syscall.FdSet{}
+	bits := int(unsafe.Sizeof(set.Bits[0]) * 8)
+	if fd >= len(set.Bits)*bits {
+		return nil
+	}
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		*set = syscall.FdSet{}
+		set.Bits[fd/bits] |= 1 << (uint(fd) % uint(bits))
+		timeout := syscall.NsecToTimeval(int64(INPUT_POLL_INTERVAL))
+		err := selectRead(fd+1, set, &timeout)
+		if goerrors.Is(err, syscall.EINTR) {
+			continue
+		}
+
+		// the read reports what's wrong with the file
+		if err != nil {
+			return nil
+		}
+
+		if set.Bits[fd/bits]&(1<<(uint(fd)%uint(bits))) != 0 {
+			return nil
+		}
+	}
+}
@@ -1,7 +1,11 @@
 package interpreting
 
 import (
+	"bufio"
+	"context"
 	"fmt"
+	"io"
+	"os"
 	"reflect"
 	"time"
 
@@ -16,12 +20,51 @@ import (
 // identical objects to be unique
 type exprId string
 
+// implemented by values that expose properties through the '.' operator,
+// like instances, lists and native modules
+type propertyHolder interface {
+	Get(name token.Token) (any, error)
+}
+
+// Interpreter runs programs, keeping their state between calls to Interpret. An interpreter must only be used
+// by one goroutine at a time, but separate interpreters share nothing and can run in parallel, even on the same
+// statements, as long as each is resolved by a resolver of its own interpreter. Natives that change the process,
+// like 'os.setenv', still affect every interpreter, and so does the process' standard input and output, which
+// interpreters use unless they're given their own with SetInput and SetOutput
 type Interpreter struct {
 	globals     *environment.Environment
 	env         *environment.Environment
 	locals      map[exprId]int
 	isReturning bool
 	returnValue any
+	// directory that file system natives are restricted to, an empty root means no restriction
+	root string
+	// command-line arguments passed to the script, returned by the 'args' native
+	args []string
+	// shared by all input natives, so input buffered by one read isn't lost to the next
+	stdin *bufio.Reader
+	// the file stdin buffers when it's shared with SetSharedInput, nil otherwise
+	sharedInput *os.File
+	// where print statements and natives like 'input' write to
+	stdout io.Writer
+	// what natives are allowed to do outside of the interpreter
+	permissions Permissions
+	// makes global declarations of functions and classes update the ones they redeclare
+	redefineInPlace bool
+	limits          Limits
+	// resources used by the current call to Interpret, checked against the limits
+	steps     int
+	memory    int
+	callDepth int
+	// only set while InterpretContext runs with a context that can be done
+	ctx context.Context
+	// the innermost statement being executed, which tells where internal errors happened
+	statement ast.Stmt
+	// both are nil unless a debugger is attached
+	debugger Debugger
+	frames   []Frame
+	// observers are only notified through loops over this slice, which cost nothing while it's empty
+	observers []Observer
 }
 
 func NewInterpreter() *Interpreter {
@@ -29,26 +72,118 @@ func NewInterpreter() *Interpreter {
 
 	globals.Define("clock", &nativeFunction{
 		arity: 0,
-		call: func(interpreter *Interpreter, arguments []any) (any, error) {
-			return float64(time.Now().Unix()), nil
+		call: func(interpreter *Interpreter, paren token.Token, arguments []any) (any, error) {
+			return float64(time.Now().UnixNano()) / float64(time.Second), nil
 		},
 	})
 
 	globals.Define("str", &nativeFunction{
 		arity: 1,
-		call: func(interpreter *Interpreter, arguments []any) (any, error) {
+		call: func(interpreter *Interpreter, paren token.Token, arguments []any) (any, error) {
 			return stringify(arguments[0]), nil
 		},
 	})
 
-	return &Interpreter{
-		globals: globals,
-		env:     globals,
-		locals:  map[exprId]int{},
+	globals.Define("list", &nativeFunction{
+		arity: 0,
+		call: func(interpreter *Interpreter, paren token.Token, arguments []any) (any, error) {
+			return newList([]any{}), nil
+		},
+	})
+
+	globals.Define("map", &nativeFunction{
+		arity: 0,
+		call: func(interpreter *Interpreter, paren token.Token, arguments []any) (any, error) {
+			return newHashMap(), nil
+		},
+	})
+
+	defineFsNatives(globals)
+	defineIoNatives(globals)
+	defineOsModule(globals)
+	defineTimeModule(globals)
+	defineJsonModule(globals)
+	defineRegexModule(globals)
+
+	interpreter := &Interpreter{
+		globals:     globals,
+		env:         globals,
+		locals:      map[exprId]int{},
+		stdout:      os.Stdout,
+		permissions: AllPermissions(),
+		limits:      DefaultLimits(),
+	}
+	interpreter.SetInput(os.Stdin)
+	return interpreter
+}
+
+// SetRoot restricts the file system natives to the given directory.
+// Paths used by scripts are resolved relative to it and may not escape it, not even through symbolic links.
+func (i *Interpreter) SetRoot(dir string) error {
+	root, err := realPath(dir)
+	if err != nil {
+		return err
+	}
+
+	i.root = root
+	return nil
+}
+
+// SetArgs sets the command-line arguments available to the script through the 'args' native.
+func (i *Interpreter) SetArgs(args []string) {
+	i.args = args
+}
+
+// SetInput sets where the input natives, like 'readLine', read from. It's the process' standard input by default,
+// which interpreters running at the same time split between them unpredictably, so they should each get their own.
+// Reads from files stop when the context passed to InterpretContext is done, on Linux and macOS, but reads from
+// other readers can't be interrupted, and a script waiting on one only stops once the read returns
+func (i *Interpreter) SetInput(in io.Reader) {
+	if file, ok := in.(*os.File); ok {
+		in = &cancelableReader{file: file, interpreter: i}
+	}
+
+	i.stdin = bufio.NewReader(in)
+	i.sharedInput = nil
+}
+
+// SetSharedInput is like SetInput, but the input natives read through reader, which buffers file and is also used by
+// something else reading file, like a line editor, so neither takes input buffered by the other.
+// Waiting for file to have input can be interrupted like with SetInput, but waiting for the rest of a line can't
+func (i *Interpreter) SetSharedInput(reader *bufio.Reader, file *os.File) {
+	i.stdin = reader
+	i.sharedInput = file
+}
+
+// SetOutput sets where print statements and natives like 'input' write to. It's the process' standard output by default
+func (i *Interpreter) SetOutput(out io.Writer) {
+	i.stdout = out
+}
+
+// SetSandboxed enables sandbox mode, which disables natives like 'os.exec' by taking away the exec permission.
+// Disabling it doesn't grant the permission back, since the host may have denied it, SetPermissions does that
+func (i *Interpreter) SetSandboxed(sandboxed bool) {
+	if sandboxed {
+		i.permissions.Exec = false
 	}
 }
 
-func (i *Interpreter) Interpret(statements []ast.Stmt) error {
+// SetRedefineInPlace makes global function and class declarations that redeclare a function or class update it
+// in place, instead of defining a new one. Values already referring to it, like instances of a class or variables
+// holding a function, then see the new definition. Used by the REPL
+func (i *Interpreter) SetRedefineInPlace(redefineInPlace bool) {
+	i.redefineInPlace = redefineInPlace
+}
+
+// Interpret runs statements, returning the runtime error that stopped them. A failed internal assertion is
+// returned as an errors.InternalError instead of crashing the process, only this interpreter is affected by it
+func (i *Interpreter) Interpret(statements []ast.Stmt) (err error) {
+	defer errors.RecoverAssertion(i.position, func(internalErr *errors.InternalError) {
+		i.statement = nil
+		err = internalErr
+	})
+	i.steps = 0
+	i.memory = 0
 	for _, statement := range statements {
 		if err := i.execute(statement); err != nil {
 			return err
@@ -91,7 +226,22 @@ func (i *Interpreter) VisitCallExpr(expr *ast.CallExpr) (any, error) {
 		return nil, errors.NewRuntimeError(expr.Paren, fmt.Sprintf("expected %d arguments but got %d instead", callable.Arity(), len(arguments)))
 	}
 
-	return callable.Call(i, arguments)
+	var result any
+	if len(i.observers) > 0 {
+		result, err = i.call(callable, expr.Paren, arguments)
+	} else {
+		result, err = callable.Call(i, expr.Paren, arguments)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := i.allocate(expr.Paren, callSize(callable, result)); err != nil {
+		return nil, err
+	}
+
+	return result, nil
 }
 
 func (i *Interpreter) VisitGetExpr(expr *ast.GetExpr) (any, error) {
@@ -100,12 +250,12 @@ func (i *Interpreter) VisitGetExpr(expr *ast.GetExpr) (any, error) {
 		return nil, err
 	}
 
-	instance, ok := object.(*Instance)
+	holder, ok := object.(propertyHolder)
 	if !ok {
 		return nil, errors.NewRuntimeError(expr.Name, "only instances can have properties")
 	}
 
-	return instance.Get(expr.Name)
+	return holder.Get(expr.Name)
 }
 
 func (i *Interpreter) VisitSetExpr(expr *ast.SetExpr) (any, error) {
@@ -124,6 +274,10 @@ func (i *Interpreter) VisitSetExpr(expr *ast.SetExpr) (any, error) {
 		return nil, err
 	}
 
+	if err := i.allocate(expr.Name, VALUE_SIZE+len(expr.Name.Lexeme)); err != nil {
+		return nil, err
+	}
+
 	instance.Set(expr.Name, value)
 
 	return value, nil
@@ -185,10 +339,14 @@ func (i *Interpreter) VisitBinaryExpr(expr *ast.BinaryExpr) (any, error) {
 		}
 
 		if isString(left) && isString(right) {
+			if err := i.allocate(expr.Operator, len(left.(string))+len(right.(string))); err != nil {
+				return nil, err
+			}
+
 			return left.(string) + right.(string), nil
 		}
 
-		return errors.NewRuntimeError(expr.Operator, "operands must be two numbers or two strings"), nil
+		return nil, errors.NewRuntimeError(expr.Operator, "operands must be two numbers or two strings")
 	case token.MINUS:
 		if err := checkNumberOperandBinary(expr.Operator, left, right); err != nil {
 			return nil, err
@@ -228,14 +386,8 @@ func (i *Interpreter) VisitBinaryExpr(expr *ast.BinaryExpr) (any, error) {
 		}
 		return left.(float64) <= right.(float64), nil
 	case token.EQUAL_EQUAL:
-		if err := checkNumberOperandBinary(expr.Operator, left, right); err != nil {
-			return nil, err
-		}
 		return left == right, nil
 	case token.BANG_EQUAL:
-		if err := checkNumberOperandBinary(expr.Operator, left, right); err != nil {
-			return nil, err
-		}
 		return left != right, nil
 	}
 
@@ -251,8 +403,15 @@ func (i *Interpreter) VisitLogicalExpr(expr *ast.LogicalExpr) (any, error) {
 		return nil, err
 	}
 
-	if expr.Operator.Kind == token.OR && isTruthy(left) ||
-		expr.Operator.Kind == token.AND && !isTruthy(left) {
+	shortCircuits := expr.Operator.Kind == token.OR && isTruthy(left) ||
+		expr.Operator.Kind == token.AND && !isTruthy(left)
+	for _, observer := range i.observers {
+		if branchObserver, ok := observer.(BranchObserver); ok {
+			branchObserver.OnBranch(expr, !shortCircuits)
+		}
+	}
+
+	if shortCircuits {
 		return left, nil
 	}
 
@@ -268,7 +427,7 @@ func (i *Interpreter) VisitVariableExpr(expr *ast.VariableExpr) (any, error) {
 	return i.lookupVariable(expr.Name, expr)
 }
 
-func (i Interpreter) lookupVariable(name token.Token, expr ast.Expr) (any, error) {
+func (i *Interpreter) lookupVariable(name token.Token, expr ast.Expr) (any, error) {
 	distance, ok := i.locals[makeExprId(expr)]
 	if !ok {
 		return i.globals.Get(name)
@@ -292,6 +451,10 @@ func (i *Interpreter) VisitAssignmentExpr(expr *ast.AssignmentExpr) (any, error)
 		}
 	}
 
+	for _, observer := range i.observers {
+		observer.OnAssignment(expr.Name, value)
+	}
+
 	return value, nil
 }
 
@@ -301,7 +464,7 @@ func (i *Interpreter) VisitPrintStmt(stmt *ast.PrintStmt) (any, error) {
 		return nil, err
 	}
 
-	fmt.Println(stringify(value))
+	fmt.Fprintln(i.stdout, stringify(value))
 
 	return nil, nil
 }
@@ -328,6 +491,12 @@ func (i *Interpreter) VisitWhileStmt(stmt *ast.WhileStmt) (any, error) {
 			return nil, err
 		}
 
+		for _, observer := range i.observers {
+			if branchObserver, ok := observer.(BranchObserver); ok {
+				branchObserver.OnBranch(stmt, isTruthy(condition))
+			}
+		}
+
 		if !isTruthy(condition) {
 			return nil, nil
 		}
@@ -339,6 +508,17 @@ func (i *Interpreter) VisitWhileStmt(stmt *ast.WhileStmt) (any, error) {
 		if i.isReturning {
 			return nil, nil
 		}
+
+		// the position is only looked up once the context is done, to keep loops fast.
+		// Conditions like 'true' have no tokens, so the body's is used for them
+		if i.ctx != nil && i.ctx.Err() != nil {
+			tok, ok := ast.ExprToken(stmt.Condition)
+			if !ok {
+				tok, _ = ast.StmtToken(stmt.Body)
+			}
+
+			return nil, i.checkContext(tok)
+		}
 	}
 }
 
@@ -348,6 +528,12 @@ func (i *Interpreter) VisitIfStmt(stmt *ast.IfStmt) (any, error) {
 		return nil, err
 	}
 
+	for _, observer := range i.observers {
+		if branchObserver, ok := observer.(BranchObserver); ok {
+			branchObserver.OnBranch(stmt, isTruthy(condition))
+		}
+	}
+
 	if isTruthy(condition) {
 		if err := i.execute(stmt.ThenBranch); err != nil {
 			return nil, err
@@ -396,6 +582,10 @@ func (i *Interpreter) VisitClassStmt(stmt *ast.ClassStmt) (any, error) {
 		superClass = superClassInstance
 	}
 
+	previous, _ := i.env.Value(stmt.Name.Lexeme)
+	existing, isClass := previous.(*class)
+	// a class only assigned to this name, like 'var B = A;', is left alone, along with its other names
+	isClass = isClass && existing.name == stmt.Name.Lexeme
 	i.env.Define(stmt.Name.Lexeme, nil)
 
 	if superClass != nil {
@@ -423,6 +613,18 @@ func (i *Interpreter) VisitClassStmt(stmt *ast.ClassStmt) (any, error) {
 		i.env = i.env.Enclosing()
 	}
 
+	if isClass && i.redefineInPlace && i.env == i.globals {
+		for ancestor := superClass; ancestor != nil; ancestor = ancestor.superClass {
+			if ancestor == existing {
+				i.env.Assign(stmt.Name, existing)
+				return nil, errors.NewRuntimeError(stmt.Name, "a class can't inherit from itself")
+			}
+		}
+
+		*existing = *class
+		class = existing
+	}
+
 	i.env.Assign(stmt.Name, class)
 
 	return nil, nil
@@ -439,11 +641,33 @@ func (i *Interpreter) VisitVarStmt(stmt *ast.VarStmt) (any, error) {
 		}
 	}
 
+	if err := i.allocate(stmt.Name, VALUE_SIZE); err != nil {
+		return nil, err
+	}
+
 	i.env.Define(stmt.Name.Lexeme, value)
+
+	for _, observer := range i.observers {
+		observer.OnAssignment(stmt.Name, value)
+	}
+
 	return nil, nil
 }
 
 func (i *Interpreter) VisitFunctionStmt(stmt *ast.FunctionStmt) (any, error) {
+	previous, _ := i.env.Value(stmt.Name.Lexeme)
+	// a function only assigned to this name, like 'var g = f;', is left alone, along with its other names
+	existing, ok := previous.(*function)
+	if ok && existing.declaration.Name.Lexeme == stmt.Name.Lexeme && i.redefineInPlace && i.env == i.globals {
+		existing.declaration = stmt
+		existing.closure = i.env
+		return nil, nil
+	}
+
+	if err := i.allocate(stmt.Name, FUNCTION_SIZE); err != nil {
+		return nil, err
+	}
+
 	fun := &function{
 		declaration:   stmt,
 		closure:       i.env,
@@ -490,7 +714,27 @@ func (i *Interpreter) executeBlock(statements []ast.Stmt, env *environment.Envir
 }
 
 func (i *Interpreter) execute(stmt ast.Stmt) error {
+	if i.limits.MaxSteps > 0 {
+		if err := i.step(); err != nil {
+			return err
+		}
+	}
+
+	if i.debugger != nil {
+		if err := i.debugStatement(stmt); err != nil {
+			return err
+		}
+	}
+
+	for _, observer := range i.observers {
+		observer.OnStatement(stmt)
+	}
+
+	// only restored when the statement returns, so the innermost one is kept when an assertion fails
+	enclosing := i.statement
+	i.statement = stmt
 	_, err := stmt.Accept(i)
+	i.statement = enclosing
 	return err
 }
 
@@ -499,6 +743,53 @@ func (i *Interpreter) Resolve(expr ast.Expr, depth int) {
 	i.locals[id] = depth
 }
 
+// ResolveGlobal records that expr refers to a global. Ids are addresses, which new nodes can reuse
+// once old ones are garbage collected, so a depth recorded for an old node has to be forgotten
+func (i *Interpreter) ResolveGlobal(expr ast.Expr) {
+	delete(i.locals, makeExprId(expr))
+}
+
+// IsGlobal reports whether name is currently defined as a global, like the natives
+func (i *Interpreter) IsGlobal(name string) bool {
+	return i.globals.IsDefined(name)
+}
+
+// GlobalNames returns the names of the globals currently defined, like the natives
+func (i *Interpreter) GlobalNames() []string {
+	return i.globals.Names()
+}
+
+// Depth returns the number of scopes between the use of a variable in expr and its declaration,
+// as resolved by the resolver. Returns false for globals
+func (i *Interpreter) Depth(expr ast.Expr) (int, bool) {
+	depth, ok := i.locals[makeExprId(expr)]
+	return depth, ok
+}
+
+// Evaluate returns the value of expr, which must have been resolved
+func (i *Interpreter) Evaluate(expr ast.Expr) (value any, err error) {
+	where := func() (int, int) {
+		if i.statement != nil {
+			return i.position()
+		}
+
+		tok, _ := ast.ExprToken(expr)
+		return tok.Line, tok.Column
+	}
+
+	defer errors.RecoverAssertion(where, func(internalErr *errors.InternalError) {
+		i.statement = nil
+		err = internalErr
+	})
+	return i.evaluate(expr)
+}
+
+// where the innermost statement being executed is, a line of 0 when that isn't known
+func (i *Interpreter) position() (int, int) {
+	tok, _ := ast.StmtToken(i.statement)
+	return tok.Line, tok.Column
+}
+
 func (i *Interpreter) evaluate(expr ast.Expr) (any, error) {
 	return expr.Accept(i)
 }
@@ -515,6 +806,43 @@ func isTruthy(item any) bool {
 	return true
 }
 
+// Stringify formats a value the way the 'print' statement does
+func Stringify(value any) string {
+	return stringify(value)
+}
+
+// TypeOf returns the name of the type of a value, like "number" or "instance of Foo"
+func TypeOf(value any) string {
+	switch value := value.(type) {
+	case nil:
+		return "nil"
+	case float64:
+		return "number"
+	case string:
+		return "string"
+	case bool:
+		return "boolean"
+	case *function:
+		return "function"
+	case *nativeFunction:
+		return "native function"
+	case *class:
+		return "class"
+	case *Instance:
+		return "instance of " + value.class.name
+	case *list:
+		return "list"
+	case *hashMap:
+		return "map"
+	case *module:
+		return "module"
+	case *regex:
+		return "regex"
+	}
+
+	return fmt.Sprintf("%T", value)
+}
+
 func stringify(item any) string {
 	if item == nil {
 		return "nil"
@@ -0,0 +1,138 @@
+package interpreting
+
+import (
+	goerrors "errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/Drumstickz64/golox/environment"
+	"github.com/Drumstickz64/golox/errors"
+	"github.com/Drumstickz64/golox/token"
+)
+
+// returned by the 'exit' native to unwind the interpreter, the host is expected to
+// exit with Code once Interpret returns it
+type ExitError struct {
+	Code int
+}
+
+func (e *ExitError) Error() string {
+	return fmt.Sprintf("script exited with code %d", e.Code)
+}
+
+func defineIoNatives(globals *environment.Environment) {
+	globals.Define("args", &nativeFunction{
+		arity: 0,
+		call: func(interpreter *Interpreter, paren token.Token, arguments []any) (any, error) {
+			args := make([]any, 0, len(interpreter.args))
+			for _, arg := range interpreter.args {
+				args = append(args, arg)
+			}
+
+			return newList(args), nil
+		},
+	})
+
+	globals.Define("input", &nativeFunction{
+		arity: 1,
+		call: func(interpreter *Interpreter, paren token.Token, arguments []any) (any, error) {
+			fmt.Fprint(interpreter.stdout, stringify(arguments[0]))
+			return interpreter.readLine(paren)
+		},
+	})
+
+	globals.Define("readLine", &nativeFunction{
+		arity: 0,
+		call: func(interpreter *Interpreter, paren token.Token, arguments []any) (any, error) {
+			return interpreter.readLine(paren)
+		},
+	})
+
+	globals.Define("readAll", &nativeFunction{
+		arity: 0,
+		call: func(interpreter *Interpreter, paren token.Token, arguments []any) (any, error) {
+			content := []byte{}
+			err := interpreter.waitForInput()
+			if err == nil {
+				content, err = io.ReadAll(interpreter.stdin)
+			}
+
+			if err := interpreter.checkContext(paren); err != nil {
+				return nil, err
+			}
+
+			if err != nil {
+				return nil, errors.NewRuntimeError(paren, fmt.Sprintf("failed to read input: %v", err))
+			}
+
+			return string(content), nil
+		},
+	})
+
+	globals.Define("exit", &nativeFunction{
+		arity: 1,
+		call: func(interpreter *Interpreter, paren token.Token, arguments []any) (any, error) {
+			code, err := integerArgument(paren, "exit", arguments, 0)
+			if err != nil {
+				return nil, err
+			}
+
+			return nil, &ExitError{Code: code}
+		},
+	})
+}
+
+// reads a single line from the interpreter's input without the line terminator,
+// returns nil once the input is exhausted
+func (i *Interpreter) readLine(paren token.Token) (any, error) {
+	line := ""
+	err := i.waitForInput()
+	if err == nil {
+		line, err = i.stdin.ReadString('\n')
+	}
+
+	if err := i.checkContext(paren); err != nil {
+		return nil, err
+	}
+
+	if err != nil {
+		if !goerrors.Is(err, io.EOF) {
+			return nil, errors.NewRuntimeError(paren, fmt.Sprintf("failed to read input: %v", err))
+		}
+
+		if line == "" {
+			return nil, nil
+		}
+	}
+
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+// waits until shared input has something to read, in a way the interpreter's context can interrupt.
+// Input set with SetInput is waited for as it's read instead
+func (i *Interpreter) waitForInput() error {
+	if i.sharedInput == nil || i.ctx == nil || i.stdin.Buffered() > 0 {
+		return nil
+	}
+
+	return waitReadable(i.ctx, i.sharedInput)
+}
+
+// reads a file, waiting for input in a way the interpreter's context can interrupt. Reads aren't left running
+// in the background when a script is canceled, where they would take input meant for whatever reads next
+type cancelableReader struct {
+	file        *os.File
+	interpreter *Interpreter
+}
+
+func (r *cancelableReader) Read(p []byte) (int, error) {
+	if ctx := r.interpreter.ctx; ctx != nil {
+		if err := waitReadable(ctx, r.file); err != nil {
+			return 0, err
+		}
+	}
+
+	return r.file.Read(p)
+}
@@ -0,0 +1,290 @@
+package interpreting
+
+import (
+	"bytes"
+	"encoding/json"
+	goerrors "errors"
+	"fmt"
+	"io"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/Drumstickz64/golox/environment"
+	"github.com/Drumstickz64/golox/errors"
+	"github.com/Drumstickz64/golox/token"
+)
+
+func defineJsonModule(globals *environment.Environment) {
+	globals.Define("json", newModule("json", map[string]any{
+		"parse": &nativeFunction{
+			arity: 1,
+			call: func(interpreter *Interpreter, paren token.Token, arguments []any) (any, error) {
+				text, err := stringArgument(paren, "parse", arguments, 0)
+				if err != nil {
+					return nil, err
+				}
+
+				return parseJson(paren, text)
+			},
+		},
+		// indent is the number of spaces used for each level of nesting, 0 produces compact output
+		"stringify": &nativeFunction{
+			arity: 2,
+			call: func(interpreter *Interpreter, paren token.Token, arguments []any) (any, error) {
+				indent, err := integerArgument(paren, "stringify", arguments, 1)
+				if err != nil {
+					return nil, err
+				}
+
+				encoder := &jsonEncoder{
+					interpreter: interpreter,
+					paren:       paren,
+					indent:      strings.Repeat(" ", max(indent, 0)),
+					visiting:    map[any]bool{},
+				}
+				if err := encoder.encode(arguments[0], 0); err != nil {
+					return nil, err
+				}
+
+				return encoder.builder.String(), nil
+			},
+		},
+	}))
+}
+
+func parseJson(paren token.Token, text string) (any, error) {
+	decoder := json.NewDecoder(strings.NewReader(text))
+	value, err := decodeJsonValue(decoder)
+	if err != nil {
+		return nil, jsonError(paren, text, decoder, err)
+	}
+
+	// only whitespace may come after the top-level value
+	end := decoder.InputOffset()
+	if _, err := decoder.Token(); !goerrors.Is(err, io.EOF) {
+		trailing := int64(len(text) - len(strings.TrimLeft(text[end:], " \t\r\n")))
+		line, column := jsonPosition(text, trailing)
+		return nil, errors.NewRuntimeError(paren, fmt.Sprintf("invalid JSON at %d:%d: unexpected data after top-level value", line, column))
+	}
+
+	return value, nil
+}
+
+func jsonError(paren token.Token, text string, decoder *json.Decoder, err error) error {
+	offset := decoder.InputOffset()
+	var syntaxErr *json.SyntaxError
+	if goerrors.As(err, &syntaxErr) {
+		// the offset of a syntax error is just past the offending character,
+		// unless the input ended early, in which case it's the end of the input
+		offset = syntaxErr.Offset
+		if offset < int64(len(text)) {
+			offset--
+		}
+	}
+
+	if goerrors.Is(err, io.EOF) || goerrors.Is(err, io.ErrUnexpectedEOF) {
+		err = io.ErrUnexpectedEOF
+		offset = int64(len(text))
+	}
+
+	line, column := jsonPosition(text, offset)
+	return errors.NewRuntimeError(paren, fmt.Sprintf("invalid JSON at %d:%d: %v", line, column, err))
+}
+
+func decodeJsonValue(decoder *json.Decoder) (any, error) {
+	tok, err := decoder.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	switch tok := tok.(type) {
+	case json.Delim:
+		switch tok {
+		case '[':
+			elements := []any{}
+			for decoder.More() {
+				element, err := decodeJsonValue(decoder)
+				if err != nil {
+					return nil, err
+				}
+
+				elements = append(elements, element)
+			}
+
+			// consume ]
+			if _, err := decoder.Token(); err != nil {
+				return nil, err
+			}
+
+			return newList(elements), nil
+		case '{':
+			object := newHashMap()
+			for decoder.More() {
+				key, err := decoder.Token()
+				if err != nil {
+					return nil, err
+				}
+
+				value, err := decodeJsonValue(decoder)
+				if err != nil {
+					return nil, err
+				}
+
+				object.set(key, value)
+			}
+
+			// consume }
+			if _, err := decoder.Token(); err != nil {
+				return nil, err
+			}
+
+			return object, nil
+		}
+
+		return nil, fmt.Errorf("unexpected '%v'", tok)
+	default:
+		// strings, float64s, bools and nil already match the values used by the interpreter
+		return tok, nil
+	}
+}
+
+// converts a byte offset into a 1-based line and column
+func jsonPosition(text string, offset int64) (int, int) {
+	offset = min(offset, int64(len(text)))
+	before := text[:offset]
+	line := strings.Count(before, "\n") + 1
+	column := int(offset) - strings.LastIndex(before, "\n")
+	return line, column
+}
+
+type jsonEncoder struct {
+	interpreter *Interpreter
+	paren       token.Token
+	indent      string
+	builder     strings.Builder
+	// lists, maps and instances currently being encoded, used to detect cycles
+	visiting map[any]bool
+}
+
+func (e *jsonEncoder) encode(value any, depth int) error {
+	switch value := value.(type) {
+	case nil:
+		e.builder.WriteString("null")
+	case bool:
+		e.builder.WriteString(strconv.FormatBool(value))
+	case float64:
+		if math.IsNaN(value) || math.IsInf(value, 0) {
+			return errors.NewRuntimeError(e.paren, fmt.Sprintf("can't convert %v to JSON", value))
+		}
+
+		e.builder.WriteString(strconv.FormatFloat(value, 'f', -1, 64))
+	case string:
+		e.encodeString(value)
+	case *list:
+		return e.encodeContainer(value, depth, '[', ']', len(value.elements), func(index int) error {
+			return e.encode(value.elements[index], depth+1)
+		})
+	case *hashMap:
+		return e.encodeContainer(value, depth, '{', '}', len(value.keys), func(index int) error {
+			key, ok := value.keys[index].(string)
+			if !ok {
+				return errors.NewRuntimeError(e.paren, fmt.Sprintf("can't convert map with key '%s' to JSON, keys must be strings", stringify(value.keys[index])))
+			}
+
+			return e.encodeField(key, value.values[key], depth)
+		})
+	case *Instance:
+		if toJson, ok := value.class.findMethod("toJSON"); ok {
+			if toJson.Arity() != 0 {
+				return errors.NewRuntimeError(e.paren, "'toJSON' method must take no arguments")
+			}
+
+			// a 'toJSON' returning the instance itself, or something leading back to it, would never end
+			if e.visiting[value] {
+				return errors.NewRuntimeError(e.paren, "can't convert cyclic value to JSON")
+			}
+			e.visiting[value] = true
+			defer delete(e.visiting, value)
+
+			result, err := e.interpreter.call(toJson.bind(value), e.paren, []any{})
+			if err != nil {
+				return err
+			}
+
+			return e.encode(result, depth)
+		}
+
+		// instance fields are unordered, so they're sorted to produce stable output
+		names := make([]string, 0, len(value.fields))
+		for name := range value.fields {
+			names = append(names, name)
+		}
+		slices.Sort(names)
+
+		return e.encodeContainer(value, depth, '{', '}', len(names), func(index int) error {
+			return e.encodeField(names[index], value.fields[names[index]], depth)
+		})
+	default:
+		return errors.NewRuntimeError(e.paren, fmt.Sprintf("can't convert %s to JSON", stringify(value)))
+	}
+
+	return nil
+}
+
+func (e *jsonEncoder) encodeContainer(container any, depth int, open, close byte, length int, encodeItem func(index int) error) error {
+	if e.visiting[container] {
+		return errors.NewRuntimeError(e.paren, "can't convert cyclic value to JSON")
+	}
+	e.visiting[container] = true
+	defer delete(e.visiting, container)
+
+	e.builder.WriteByte(open)
+	for index := 0; index < length; index++ {
+		if index > 0 {
+			e.builder.WriteByte(',')
+		}
+
+		e.newline(depth + 1)
+		if err := encodeItem(index); err != nil {
+			return err
+		}
+	}
+
+	if length > 0 {
+		e.newline(depth)
+	}
+	e.builder.WriteByte(close)
+
+	return nil
+}
+
+func (e *jsonEncoder) encodeField(key string, value any, depth int) error {
+	e.encodeString(key)
+	e.builder.WriteByte(':')
+	if e.indent != "" {
+		e.builder.WriteByte(' ')
+	}
+
+	return e.encode(value, depth+1)
+}
+
+func (e *jsonEncoder) encodeString(value string) {
+	// characters like '<' are kept as they are, instead of being escaped for embedding in HTML.
+	// Encoding a string never fails
+	var encoded bytes.Buffer
+	encoder := json.NewEncoder(&encoded)
+	encoder.SetEscapeHTML(false)
+	encoder.Encode(value)
+	e.builder.Write(bytes.TrimSuffix(encoded.Bytes(), []byte("\n")))
+}
+
+func (e *jsonEncoder) newline(depth int) {
+	if e.indent == "" {
+		return
+	}
+
+	e.builder.WriteByte('\n')
+	e.builder.WriteString(strings.Repeat(e.indent, depth))
+}
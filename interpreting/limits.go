@@ -0,0 +1,155 @@
+package interpreting
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Drumstickz64/golox/token"
+)
+
+// Limits bound the resources a script can use, a limit of 0 means there is no limit
+type Limits struct {
+	// the most statements executed by a call to Interpret
+	MaxSteps int
+	// the most calls of Lox functions in progress at once, which keeps deep recursion from overflowing the Go stack.
+	// Without it, a script recursing too deeply crashes the whole process
+	MaxCallDepth int
+	// the most bytes allocated by a call to Interpret, approximately. Memory is counted when it's allocated and never
+	// given back, so this bounds everything the script allocates rather than how much of it is in use at once
+	MaxMemory int
+}
+
+// the call depth interpreters start with, deep enough for any reasonable recursion
+// and far below where the Go stack overflows
+const DEFAULT_MAX_CALL_DEPTH = 10000
+
+// DefaultLimits only bounds the call depth, it's what interpreters start with
+func DefaultLimits() Limits {
+	return Limits{MaxCallDepth: DEFAULT_MAX_CALL_DEPTH}
+}
+
+type Limit int
+
+const (
+	LIMIT_STEPS Limit = iota
+	LIMIT_CALL_DEPTH
+	LIMIT_MEMORY
+	// the deadline of the context passed to InterpretContext
+	LIMIT_TIME
+)
+
+func (l Limit) String() string {
+	switch l {
+	case LIMIT_STEPS:
+		return "step"
+	case LIMIT_CALL_DEPTH:
+		return "call depth"
+	case LIMIT_MEMORY:
+		return "memory"
+	}
+
+	return "time"
+}
+
+// approximate sizes of the values allocated by scripts, in bytes
+const (
+	VALUE_SIZE       = 16
+	ENVIRONMENT_SIZE = 64
+	INSTANCE_SIZE    = 64
+	FUNCTION_SIZE    = 48
+)
+
+// returned when a script exceeds one of the limits. Unlike other runtime errors,
+// it can't be caught by scripts, since that would let them keep going
+type LimitError struct {
+	Limit Limit
+	// where the limit was exceeded, has no position when that isn't known
+	Token token.Token
+}
+
+func (e *LimitError) Error() string {
+	return runtimeErrorAt(e.Token, fmt.Sprintf("exceeded the %s limit", e.Limit))
+}
+
+// the time limit is the deadline of a context, so the error is also context.DeadlineExceeded
+func (e *LimitError) Unwrap() error {
+	if e.Limit == LIMIT_TIME {
+		return context.DeadlineExceeded
+	}
+
+	return nil
+}
+
+// SetLimits bounds the resources used by the scripts run by the interpreter, replacing DefaultLimits.
+// A MaxCallDepth of 0 lets scripts crash the process with a stack overflow, so hosts should set one
+func (i *Interpreter) SetLimits(limits Limits) {
+	i.limits = limits
+}
+
+// counts a statement against the step limit
+func (i *Interpreter) step() error {
+	i.steps++
+	if i.steps > i.limits.MaxSteps {
+		return &LimitError{Limit: LIMIT_STEPS}
+	}
+
+	return nil
+}
+
+// counts size bytes against the memory limit, tok is where they're allocated
+func (i *Interpreter) allocate(tok token.Token, size int) error {
+	if i.limits.MaxMemory == 0 {
+		return nil
+	}
+
+	i.memory += size
+	if i.memory > i.limits.MaxMemory {
+		return &LimitError{Limit: LIMIT_MEMORY, Token: tok}
+	}
+
+	return nil
+}
+
+// approximately how many bytes a call allocates. Functions allocate their scope, classes an instance as well,
+// and the values returned by natives are counted as new, even though some of them already existed
+func callSize(callable Callable, result any) int {
+	switch callable.(type) {
+	case *function:
+		return ENVIRONMENT_SIZE
+	case *class:
+		return ENVIRONMENT_SIZE + INSTANCE_SIZE
+	}
+
+	return VALUE_SIZE + sizeOf(result)
+}
+
+// approximately how many bytes value takes, not counting the values it contains
+func sizeOf(value any) int {
+	switch value := value.(type) {
+	case string:
+		return len(value)
+	case *list:
+		return len(value.elements) * VALUE_SIZE
+	case *hashMap:
+		return len(value.keys) * VALUE_SIZE * 2
+	case *Instance:
+		return INSTANCE_SIZE
+	}
+
+	return VALUE_SIZE
+}
+
+// counts a call against the call depth limit, exitCall has to be called once it returns
+func (i *Interpreter) enterCall(paren token.Token) error {
+	i.callDepth++
+	if i.limits.MaxCallDepth > 0 && i.callDepth > i.limits.MaxCallDepth {
+		i.callDepth--
+		return &LimitError{Limit: LIMIT_CALL_DEPTH, Token: paren}
+	}
+
+	return nil
+}
+
+func (i *Interpreter) exitCall() {
+	i.callDepth--
+}
@@ -0,0 +1,102 @@
+package interpreting
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Drumstickz64/golox/errors"
+	"github.com/Drumstickz64/golox/token"
+)
+
+type list struct {
+	elements []any
+}
+
+func newList(elements []any) *list {
+	return &list{
+		elements: elements,
+	}
+}
+
+func (l *list) Get(name token.Token) (any, error) {
+	switch name.Lexeme {
+	case "length":
+		return &nativeFunction{
+			arity: 0,
+			call: func(interpreter *Interpreter, paren token.Token, arguments []any) (any, error) {
+				return float64(len(l.elements)), nil
+			},
+		}, nil
+	case "get":
+		return &nativeFunction{
+			arity: 1,
+			call: func(interpreter *Interpreter, paren token.Token, arguments []any) (any, error) {
+				index, err := l.index(paren, "get", arguments)
+				if err != nil {
+					return nil, err
+				}
+
+				return l.elements[index], nil
+			},
+		}, nil
+	case "set":
+		return &nativeFunction{
+			arity: 2,
+			call: func(interpreter *Interpreter, paren token.Token, arguments []any) (any, error) {
+				index, err := l.index(paren, "set", arguments)
+				if err != nil {
+					return nil, err
+				}
+
+				l.elements[index] = arguments[1]
+				return arguments[1], nil
+			},
+		}, nil
+	case "push":
+		return &nativeFunction{
+			arity: 1,
+			call: func(interpreter *Interpreter, paren token.Token, arguments []any) (any, error) {
+				l.elements = append(l.elements, arguments[0])
+				return nil, nil
+			},
+		}, nil
+	case "pop":
+		return &nativeFunction{
+			arity: 0,
+			call: func(interpreter *Interpreter, paren token.Token, arguments []any) (any, error) {
+				if len(l.elements) == 0 {
+					return nil, errors.NewRuntimeError(paren, "can't pop from an empty list")
+				}
+
+				last := l.elements[len(l.elements)-1]
+				l.elements = l.elements[:len(l.elements)-1]
+				return last, nil
+			},
+		}, nil
+	}
+
+	return nil, errors.NewRuntimeError(name, fmt.Sprintf("undefined property '%s'", name.Lexeme))
+}
+
+func (l *list) String() string {
+	elements := make([]string, 0, len(l.elements))
+	for _, element := range l.elements {
+		elements = append(elements, stringify(element))
+	}
+
+	return "[" + strings.Join(elements, ", ") + "]"
+}
+
+// validates the first argument as an index into the list
+func (l *list) index(paren token.Token, method string, arguments []any) (int, error) {
+	index, err := integerArgument(paren, method, arguments, 0)
+	if err != nil {
+		return 0, err
+	}
+
+	if index < 0 || index >= len(l.elements) {
+		return 0, errors.NewRuntimeError(paren, fmt.Sprintf("index %d is out of bounds for list of length %d", index, len(l.elements)))
+	}
+
+	return index, nil
+}
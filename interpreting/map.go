@@ -0,0 +1,107 @@
+package interpreting
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/Drumstickz64/golox/errors"
+	"github.com/Drumstickz64/golox/token"
+)
+
+type hashMap struct {
+	values map[any]any
+	// keeps insertion order, so printing and iterating over keys is deterministic
+	keys []any
+}
+
+func newHashMap() *hashMap {
+	return &hashMap{
+		values: map[any]any{},
+		keys:   []any{},
+	}
+}
+
+func (m *hashMap) get(key any) (any, bool) {
+	value, ok := m.values[key]
+	return value, ok
+}
+
+func (m *hashMap) set(key, value any) {
+	if _, exists := m.values[key]; !exists {
+		m.keys = append(m.keys, key)
+	}
+
+	m.values[key] = value
+}
+
+func (m *hashMap) remove(key any) {
+	if _, exists := m.values[key]; !exists {
+		return
+	}
+
+	delete(m.values, key)
+	m.keys = slices.DeleteFunc(m.keys, func(k any) bool { return k == key })
+}
+
+func (m *hashMap) Get(name token.Token) (any, error) {
+	switch name.Lexeme {
+	case "length":
+		return &nativeFunction{
+			arity: 0,
+			call: func(interpreter *Interpreter, paren token.Token, arguments []any) (any, error) {
+				return float64(len(m.keys)), nil
+			},
+		}, nil
+	case "get":
+		return &nativeFunction{
+			arity: 1,
+			call: func(interpreter *Interpreter, paren token.Token, arguments []any) (any, error) {
+				value, _ := m.get(arguments[0])
+				return value, nil
+			},
+		}, nil
+	case "set":
+		return &nativeFunction{
+			arity: 2,
+			call: func(interpreter *Interpreter, paren token.Token, arguments []any) (any, error) {
+				m.set(arguments[0], arguments[1])
+				return arguments[1], nil
+			},
+		}, nil
+	case "has":
+		return &nativeFunction{
+			arity: 1,
+			call: func(interpreter *Interpreter, paren token.Token, arguments []any) (any, error) {
+				_, ok := m.get(arguments[0])
+				return ok, nil
+			},
+		}, nil
+	case "remove":
+		return &nativeFunction{
+			arity: 1,
+			call: func(interpreter *Interpreter, paren token.Token, arguments []any) (any, error) {
+				m.remove(arguments[0])
+				return nil, nil
+			},
+		}, nil
+	case "keys":
+		return &nativeFunction{
+			arity: 0,
+			call: func(interpreter *Interpreter, paren token.Token, arguments []any) (any, error) {
+				return newList(slices.Clone(m.keys)), nil
+			},
+		}, nil
+	}
+
+	return nil, errors.NewRuntimeError(name, fmt.Sprintf("undefined property '%s'", name.Lexeme))
+}
+
+func (m *hashMap) String() string {
+	entries := make([]string, 0, len(m.keys))
+	for _, key := range m.keys {
+		entries = append(entries, stringify(key)+": "+stringify(m.values[key]))
+	}
+
+	return "{" + strings.Join(entries, ", ") + "}"
+}
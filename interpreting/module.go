@@ -0,0 +1,34 @@
+package interpreting
+
+import (
+	"fmt"
+
+	"github.com/Drumstickz64/golox/errors"
+	"github.com/Drumstickz64/golox/token"
+)
+
+// a namespace of natives, like 'os' or 'time', whose members are accessed with '.'
+type module struct {
+	name    string
+	members map[string]any
+}
+
+func newModule(name string, members map[string]any) *module {
+	return &module{
+		name:    name,
+		members: members,
+	}
+}
+
+func (m *module) Get(name token.Token) (any, error) {
+	member, ok := m.members[name.Lexeme]
+	if !ok {
+		return nil, errors.NewRuntimeError(name, fmt.Sprintf("module '%s' has no member '%s'", m.name, name.Lexeme))
+	}
+
+	return member, nil
+}
+
+func (m *module) String() string {
+	return fmt.Sprintf("<module %s>", m.name)
+}
@@ -0,0 +1,36 @@
+package interpreting
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/Drumstickz64/golox/errors"
+	"github.com/Drumstickz64/golox/token"
+)
+
+func stringArgument(paren token.Token, native string, arguments []any, index int) (string, error) {
+	value, ok := arguments[index].(string)
+	if !ok {
+		return "", errors.NewRuntimeError(paren, fmt.Sprintf("argument %d to '%s' must be a string", index+1, native))
+	}
+
+	return value, nil
+}
+
+func numberArgument(paren token.Token, native string, arguments []any, index int) (float64, error) {
+	value, ok := arguments[index].(float64)
+	if !ok {
+		return 0, errors.NewRuntimeError(paren, fmt.Sprintf("argument %d to '%s' must be a number", index+1, native))
+	}
+
+	return value, nil
+}
+
+func integerArgument(paren token.Token, native string, arguments []any, index int) (int, error) {
+	value, ok := arguments[index].(float64)
+	if !ok || value != math.Trunc(value) {
+		return 0, errors.NewRuntimeError(paren, fmt.Sprintf("argument %d to '%s' must be an integer", index+1, native))
+	}
+
+	return int(value), nil
+}
@@ -0,0 +1,66 @@
+package interpreting
+
+import (
+	"github.com/Drumstickz64/golox/ast"
+	"github.com/Drumstickz64/golox/token"
+)
+
+// Observer is notified of what the interpreter does, for tools like tracers and profilers.
+// Unlike a debugger, an observer can't affect the program
+type Observer interface {
+	// called before a statement is executed
+	OnStatement(stmt ast.Stmt)
+	// called before callee is called, paren is the closing parenthesis of the call
+	OnCall(callee Callable, paren token.Token, arguments []any)
+	// called after a call to callee ends, err is set if the call failed
+	OnReturn(callee Callable, paren token.Token, result any, err error)
+	// called when a variable is defined or assigned
+	OnAssignment(name token.Token, value any)
+}
+
+// BranchObserver can be implemented by an Observer that also wants to know which branches are picked, like a coverage recorder
+type BranchObserver interface {
+	Observer
+	// called when the interpreter picks a branch, node is the *ast.IfStmt, *ast.WhileStmt or *ast.LogicalExpr.
+	// taken is whether the then branch, the loop body or the right operand is executed
+	OnBranch(node any, taken bool)
+}
+
+// AddObserver attaches an observer, which is notified of everything the interpreter does from then on
+func (i *Interpreter) AddObserver(observer Observer) {
+	i.observers = append(i.observers, observer)
+}
+
+// RemoveObserver detaches an observer added with AddObserver
+func (i *Interpreter) RemoveObserver(observer Observer) {
+	for index, added := range i.observers {
+		if added == observer {
+			i.observers = append(i.observers[:index:index], i.observers[index+1:]...)
+			return
+		}
+	}
+}
+
+func (i *Interpreter) call(callable Callable, paren token.Token, arguments []any) (any, error) {
+	for _, observer := range i.observers {
+		observer.OnCall(callable, paren, arguments)
+	}
+
+	result, err := callable.Call(i, paren, arguments)
+
+	for _, observer := range i.observers {
+		observer.OnReturn(callable, paren, result, err)
+	}
+
+	return result, err
+}
+
+// FunctionDeclaration returns the declaration of a function or method written in Lox.
+// Natives and classes have no declaration
+func FunctionDeclaration(callable Callable) (*ast.FunctionStmt, bool) {
+	if fun, ok := callable.(*function); ok {
+		return fun.declaration, true
+	}
+
+	return nil, false
+}
@@ -0,0 +1,124 @@
+package interpreting
+
+import (
+	"bytes"
+	"context"
+	goerrors "errors"
+	"fmt"
+	"os"
+	"os/exec"
+
+	"github.com/Drumstickz64/golox/environment"
+	"github.com/Drumstickz64/golox/errors"
+	"github.com/Drumstickz64/golox/token"
+)
+
+func defineOsModule(globals *environment.Environment) {
+	globals.Define("os", newModule("os", map[string]any{
+		"getenv": &nativeFunction{
+			arity: 1,
+			call: func(interpreter *Interpreter, paren token.Token, arguments []any) (any, error) {
+				name, err := stringArgument(paren, "getenv", arguments, 0)
+				if err != nil {
+					return nil, err
+				}
+
+				if err := interpreter.checkPermission(paren, "getenv", PERMISSION_ENV, name); err != nil {
+					return nil, err
+				}
+
+				value, ok := os.LookupEnv(name)
+				if !ok {
+					return nil, nil
+				}
+
+				return value, nil
+			},
+		},
+		"setenv": &nativeFunction{
+			arity: 2,
+			call: func(interpreter *Interpreter, paren token.Token, arguments []any) (any, error) {
+				name, err := stringArgument(paren, "setenv", arguments, 0)
+				if err != nil {
+					return nil, err
+				}
+
+				value, err := stringArgument(paren, "setenv", arguments, 1)
+				if err != nil {
+					return nil, err
+				}
+
+				if err := interpreter.checkPermission(paren, "setenv", PERMISSION_ENV, name); err != nil {
+					return nil, err
+				}
+
+				if err := os.Setenv(name, value); err != nil {
+					return nil, errors.NewRuntimeError(paren, fmt.Sprintf("failed to set environment variable: %v", err))
+				}
+
+				return nil, nil
+			},
+		},
+		"exec": &nativeFunction{
+			arity: 2,
+			call: func(interpreter *Interpreter, paren token.Token, arguments []any) (any, error) {
+				name, err := stringArgument(paren, "exec", arguments, 0)
+				if err != nil {
+					return nil, err
+				}
+
+				if err := interpreter.checkPermission(paren, "exec", PERMISSION_EXEC, name); err != nil {
+					return nil, err
+				}
+
+				argList, ok := arguments[1].(*list)
+				if !ok {
+					return nil, errors.NewRuntimeError(paren, "argument 2 to 'exec' must be a list")
+				}
+
+				args := make([]string, 0, len(argList.elements))
+				for _, element := range argList.elements {
+					arg, ok := element.(string)
+					if !ok {
+						return nil, errors.NewRuntimeError(paren, "arguments passed to 'exec' must be strings")
+					}
+
+					args = append(args, arg)
+				}
+
+				var stdout, stderr bytes.Buffer
+				ctx := interpreter.ctx
+				if ctx == nil {
+					ctx = context.Background()
+				}
+
+				// the command is killed if the context is done before it exits
+				cmd := exec.CommandContext(ctx, name, args...)
+				cmd.Stdout = &stdout
+				cmd.Stderr = &stderr
+
+				code := 0
+				err = cmd.Run()
+				if err := interpreter.checkContext(paren); err != nil {
+					return nil, err
+				}
+
+				if err != nil {
+					// a command exiting with a non-zero code is not an error for the script
+					var exitErr *exec.ExitError
+					if !goerrors.As(err, &exitErr) {
+						return nil, errors.NewRuntimeError(paren, fmt.Sprintf("failed to run command: %v", err))
+					}
+
+					code = exitErr.ExitCode()
+				}
+
+				result := newHashMap()
+				result.set("stdout", stdout.String())
+				result.set("stderr", stderr.String())
+				result.set("code", float64(code))
+				return result, nil
+			},
+		},
+	}))
+}
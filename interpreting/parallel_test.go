@@ -0,0 +1,149 @@
+package interpreting_test
+
+import (
+	"bytes"
+	"context"
+	goerrors "errors"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Drumstickz64/golox/ast"
+	"github.com/Drumstickz64/golox/errors"
+	"github.com/Drumstickz64/golox/interpreting"
+	"github.com/Drumstickz64/golox/parsing"
+	"github.com/Drumstickz64/golox/resolving"
+	"github.com/Drumstickz64/golox/scanning"
+)
+
+const PARALLEL_INTERPRETERS = 32
+
+const PARALLEL_SOURCE = `
+class A { init(x) { this.x = x; } get() { return this.x; } }
+class B < A { get() { return super.get() * 2; } }
+fun fib(n) { if (n < 2) return n; return fib(n - 1) + fib(n - 2); }
+var l = list();
+for (var i = 0; i < 50; i = i + 1) l.push(B(i).get());
+var m = map();
+m.set("fib", fib(12));
+print json.stringify(m, 0);
+print l.length();
+print readLine();
+`
+
+func build(t *testing.T, source string) []ast.Stmt {
+	t.Helper()
+	scanner := scanning.NewScanner(source)
+	tokens, errs := scanner.ScanTokens()
+	if len(errs) > 0 {
+		t.Fatal(errs)
+	}
+
+	parser := parsing.NewParser(tokens)
+	statements, errs := parser.Parse()
+	if len(errs) > 0 {
+		t.Fatal(errs)
+	}
+
+	return statements
+}
+
+// creates an interpreter that reads input and writes to out, and resolves statements with it
+func newInterpreter(t *testing.T, statements []ast.Stmt, input io.Reader, out io.Writer) *interpreting.Interpreter {
+	interpreter := interpreting.NewInterpreter()
+	interpreter.SetInput(input)
+	interpreter.SetOutput(out)
+	resolver := resolving.NewResolver(interpreter)
+	resolver.SetErrorOutput(io.Discard)
+	if hadError := resolver.Resolve(statements); hadError {
+		t.Error(resolver.Errors())
+	}
+
+	return interpreter
+}
+
+func TestParallelInterpreters(t *testing.T) {
+	shared := build(t, PARALLEL_SOURCE)
+	// one of the interpreters is given a broken depth for 'print a', as a buggy resolver would write
+	broken := build(t, "{ var a = 1; print a; }")
+
+	outputs := make([]bytes.Buffer, PARALLEL_INTERPRETERS)
+	errs := make([]error, PARALLEL_INTERPRETERS)
+	var wg sync.WaitGroup
+	for n := range PARALLEL_INTERPRETERS {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			statements := shared
+			if n == 0 {
+				statements = broken
+			}
+
+			interpreter := newInterpreter(t, statements, strings.NewReader(fmt.Sprintln("input", n)), &outputs[n])
+			if n == 0 {
+				printStmt := statements[0].(*ast.BlockStmt).Statements[1].(*ast.PrintStmt)
+				interpreter.Resolve(printStmt.Expression, 3)
+			}
+
+			errs[n] = interpreter.Interpret(statements)
+		}()
+	}
+	wg.Wait()
+
+	var internalErr *errors.InternalError
+	if !goerrors.As(errs[0], &internalErr) {
+		t.Errorf("expected the broken interpreter to fail with an internal error, got %v", errs[0])
+	}
+
+	for n := 1; n < PARALLEL_INTERPRETERS; n++ {
+		if errs[n] != nil {
+			t.Errorf("interpreter %d failed: %v", n, errs[n])
+		}
+
+		expected := fmt.Sprintf("{\"fib\":144}\n50\ninput %d\n", n)
+		if outputs[n].String() != expected {
+			t.Errorf("interpreter %d printed %q, expected %q", n, outputs[n].String(), expected)
+		}
+	}
+}
+
+func TestCanceledReadLeavesInput(t *testing.T) {
+	if runtime.GOOS != "linux" && runtime.GOOS != "darwin" {
+		t.Skip("reads can only be interrupted on Linux and macOS")
+	}
+
+	reader, writer, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reader.Close()
+	defer writer.Close()
+
+	statements := build(t, "print readLine();")
+	var out bytes.Buffer
+	interpreter := newInterpreter(t, statements, reader, &out)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+	if err := interpreter.InterpretContext(ctx, statements); !goerrors.Is(err, context.Canceled) {
+		t.Fatalf("expected the read to be canceled, got %v", err)
+	}
+
+	// the canceled read mustn't take the line meant for the next one
+	if _, err := writer.WriteString("first\nsecond\n"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := interpreter.Interpret(statements); err != nil {
+		t.Fatal(err)
+	}
+
+	if out.String() != "first\n" {
+		t.Errorf("printed %q, expected %q", out.String(), "first\n")
+	}
+}
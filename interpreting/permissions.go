@@ -0,0 +1,191 @@
+package interpreting
+
+import (
+	goerrors "errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/Drumstickz64/golox/errors"
+	"github.com/Drumstickz64/golox/token"
+)
+
+// Permissions control what natives can do outside of the interpreter. The zero value grants nothing
+type Permissions struct {
+	// files and directories that can be read, a directory includes everything inside it
+	Read []string
+	// grants reading any path, regardless of Read
+	ReadAll bool
+	// files and directories that can be written, created and removed, a directory includes everything inside it
+	Write []string
+	// grants writing any path, regardless of Write
+	WriteAll bool
+	// getting and setting environment variables
+	Env bool
+	// running other processes
+	Exec bool
+}
+
+// AllPermissions grants everything, it's what interpreters start with
+func AllPermissions() Permissions {
+	return Permissions{ReadAll: true, WriteAll: true, Env: true, Exec: true}
+}
+
+type Permission int
+
+const (
+	PERMISSION_READ Permission = iota
+	PERMISSION_WRITE
+	PERMISSION_ENV
+	PERMISSION_EXEC
+)
+
+func (p Permission) String() string {
+	switch p {
+	case PERMISSION_READ:
+		return "read"
+	case PERMISSION_WRITE:
+		return "write"
+	case PERMISSION_ENV:
+		return "env"
+	}
+
+	return "exec"
+}
+
+// returned when a native is called without the permission it needs. It's an ordinary runtime error,
+// so scripts can check whether they're allowed to do something
+type PermissionError struct {
+	Permission Permission
+	// the native that was denied
+	Native string
+	// what the native tried to access, like a path, an environment variable or a command
+	Target string
+	// closing parenthesis of the native's call
+	Token token.Token
+}
+
+func (e *PermissionError) Error() string {
+	return errors.NewRuntimeError(e.Token, fmt.Sprintf("'%s' requires the %s permission for '%s'", e.Native, e.Permission, e.Target)).Error()
+}
+
+// SetPermissions replaces the permissions of the natives. Relative paths are resolved against the working directory,
+// and symbolic links in them are followed, like they are in the paths used by scripts
+func (i *Interpreter) SetPermissions(permissions Permissions) error {
+	for _, paths := range []*[]string{&permissions.Read, &permissions.Write} {
+		resolved := make([]string, 0, len(*paths))
+		for _, pth := range *paths {
+			real, err := realPath(pth)
+			if err != nil {
+				return err
+			}
+
+			resolved = append(resolved, real)
+		}
+
+		*paths = resolved
+	}
+
+	i.permissions = permissions
+	return nil
+}
+
+// whether the permission is granted for every target, which makes checking it unnecessary
+func (p Permissions) grantsAll(permission Permission) bool {
+	switch permission {
+	case PERMISSION_READ:
+		return p.ReadAll
+	case PERMISSION_WRITE:
+		return p.WriteAll
+	case PERMISSION_ENV:
+		return p.Env
+	}
+
+	return p.Exec
+}
+
+// returns a PermissionError unless the permission is granted for target,
+// which is a path returned by realPath for the read and write permissions
+func (i *Interpreter) checkPermission(paren token.Token, native string, permission Permission, target string) error {
+	granted := i.permissions.grantsAll(permission)
+	switch permission {
+	case PERMISSION_READ:
+		granted = granted || containsPath(i.permissions.Read, target)
+	case PERMISSION_WRITE:
+		granted = granted || containsPath(i.permissions.Write, target)
+	}
+
+	if granted {
+		return nil
+	}
+
+	return &PermissionError{Permission: permission, Native: native, Target: target, Token: paren}
+}
+
+// whether pth is one of the paths in allowed, or inside one of them
+func containsPath(allowed []string, pth string) bool {
+	for _, dir := range allowed {
+		if isInside(dir, pth) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// whether pth is dir or inside it, both have to be absolute
+func isInside(dir, pth string) bool {
+	relative, err := filepath.Rel(dir, pth)
+	return err == nil && relative != ".." && !strings.HasPrefix(relative, ".."+string(filepath.Separator))
+}
+
+// returns the absolute path pth refers to, with every symbolic link in it followed, so that it can be checked
+// against the directories a script is allowed to use. Parts of it that don't exist yet can't be links, and are kept
+func realPath(pth string) (string, error) {
+	// the path isn't cleaned before its links are followed, since 'link/..' isn't always '.'
+	if !filepath.IsAbs(pth) {
+		wd, err := os.Getwd()
+		if err != nil {
+			return "", err
+		}
+
+		pth = wd + string(filepath.Separator) + pth
+	}
+
+	return followLinks(pth)
+}
+
+func followLinks(pth string) (string, error) {
+	real, err := filepath.EvalSymlinks(pth)
+	if !goerrors.Is(err, fs.ErrNotExist) {
+		return real, err
+	}
+
+	dir, file := filepath.Split(strings.TrimRight(pth, string(filepath.Separator)))
+	if dir == "" || file == "" {
+		return "", err
+	}
+
+	// a link to something that doesn't exist yet, which would be created where the link leads
+	if info, lstatErr := os.Lstat(pth); lstatErr == nil && info.Mode()&fs.ModeSymlink != 0 {
+		target, err := os.Readlink(pth)
+		if err != nil {
+			return "", err
+		}
+
+		if !filepath.IsAbs(target) {
+			target = dir + target
+		}
+
+		return followLinks(target)
+	}
+
+	realDir, err := followLinks(dir)
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(realDir, file), nil
+}
@@ -0,0 +1,169 @@
+package interpreting
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/Drumstickz64/golox/environment"
+	"github.com/Drumstickz64/golox/errors"
+	"github.com/Drumstickz64/golox/token"
+)
+
+func defineRegexModule(globals *environment.Environment) {
+	globals.Define("regex", newModule("regex", map[string]any{
+		"compile": &nativeFunction{
+			arity: 1,
+			call: func(interpreter *Interpreter, paren token.Token, arguments []any) (any, error) {
+				pattern, err := stringArgument(paren, "compile", arguments, 0)
+				if err != nil {
+					return nil, err
+				}
+
+				compiled, err := regexp.Compile(pattern)
+				if err != nil {
+					return nil, errors.NewRuntimeError(paren, fmt.Sprintf("invalid regular expression: %v", err))
+				}
+
+				return &regex{compiled: compiled}, nil
+			},
+		},
+	}))
+}
+
+type regex struct {
+	compiled *regexp.Regexp
+}
+
+func (r *regex) Get(name token.Token) (any, error) {
+	switch name.Lexeme {
+	case "match":
+		return &nativeFunction{
+			arity: 1,
+			call: func(interpreter *Interpreter, paren token.Token, arguments []any) (any, error) {
+				text, err := stringArgument(paren, "match", arguments, 0)
+				if err != nil {
+					return nil, err
+				}
+
+				return r.compiled.MatchString(text), nil
+			},
+		}, nil
+	case "find":
+		return &nativeFunction{
+			arity: 1,
+			call: func(interpreter *Interpreter, paren token.Token, arguments []any) (any, error) {
+				text, err := stringArgument(paren, "find", arguments, 0)
+				if err != nil {
+					return nil, err
+				}
+
+				indices := r.compiled.FindStringSubmatchIndex(text)
+				if indices == nil {
+					return nil, nil
+				}
+
+				return r.makeMatch(text, indices), nil
+			},
+		}, nil
+	case "findAll":
+		return &nativeFunction{
+			arity: 1,
+			call: func(interpreter *Interpreter, paren token.Token, arguments []any) (any, error) {
+				text, err := stringArgument(paren, "findAll", arguments, 0)
+				if err != nil {
+					return nil, err
+				}
+
+				matches := []any{}
+				for _, indices := range r.compiled.FindAllStringSubmatchIndex(text, -1) {
+					matches = append(matches, r.makeMatch(text, indices))
+				}
+
+				return newList(matches), nil
+			},
+		}, nil
+	case "replace":
+		return &nativeFunction{
+			arity: 2,
+			call: func(interpreter *Interpreter, paren token.Token, arguments []any) (any, error) {
+				text, err := stringArgument(paren, "replace", arguments, 0)
+				if err != nil {
+					return nil, err
+				}
+
+				switch replacement := arguments[1].(type) {
+				case string:
+					return r.compiled.ReplaceAllString(text, replacement), nil
+				case Callable:
+					return r.replaceWithCallback(interpreter, paren, text, replacement)
+				}
+
+				return nil, errors.NewRuntimeError(paren, "argument 2 to 'replace' must be a string or a function")
+			},
+		}, nil
+	}
+
+	return nil, errors.NewRuntimeError(name, fmt.Sprintf("undefined property '%s'", name.Lexeme))
+}
+
+func (r *regex) String() string {
+	return fmt.Sprintf("<regex %s>", r.compiled)
+}
+
+// replaces each match with the result of calling callback with the match
+func (r *regex) replaceWithCallback(interpreter *Interpreter, paren token.Token, text string, callback Callable) (any, error) {
+	if callback.Arity() != 1 {
+		return nil, errors.NewRuntimeError(paren, "replacement function must take exactly 1 argument")
+	}
+
+	var builder strings.Builder
+	last := 0
+	for _, indices := range r.compiled.FindAllStringSubmatchIndex(text, -1) {
+		result, err := interpreter.call(callback, paren, []any{r.makeMatch(text, indices)})
+		if err != nil {
+			return nil, err
+		}
+
+		replacement, ok := result.(string)
+		if !ok {
+			return nil, errors.NewRuntimeError(paren, "replacement function must return a string")
+		}
+
+		builder.WriteString(text[last:indices[0]])
+		builder.WriteString(replacement)
+		last = indices[1]
+	}
+	builder.WriteString(text[last:])
+
+	return builder.String(), nil
+}
+
+// converts submatch indices into a map with the matched text, its start index,
+// a list of all groups, and a map of the named groups.
+// Groups that didn't participate in the match are nil
+func (r *regex) makeMatch(text string, indices []int) *hashMap {
+	groups := []any{}
+	named := newHashMap()
+	names := r.compiled.SubexpNames()
+	// group 0 is the whole match
+	for group := 1; group < len(names); group++ {
+		var value any
+		if start, end := indices[2*group], indices[2*group+1]; start >= 0 {
+			value = text[start:end]
+		}
+
+		name := names[group]
+		groups = append(groups, value)
+		if name != "" {
+			named.set(name, value)
+		}
+	}
+
+	match := newHashMap()
+	match.set("text", text[indices[0]:indices[1]])
+	match.set("index", float64(indices[0]))
+	match.set("groups", newList(groups))
+	match.set("named", named)
+	return match
+}
@@ -0,0 +1,119 @@
+package interpreting
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/Drumstickz64/golox/environment"
+	"github.com/Drumstickz64/golox/errors"
+	"github.com/Drumstickz64/golox/token"
+)
+
+// times are represented in scripts as milliseconds since the unix epoch,
+// and durations as milliseconds
+func defineTimeModule(globals *environment.Environment) {
+	globals.Define("time", newModule("time", map[string]any{
+		"now": &nativeFunction{
+			arity: 0,
+			call: func(interpreter *Interpreter, paren token.Token, arguments []any) (any, error) {
+				return toMilliseconds(time.Now()), nil
+			},
+		},
+		"sleep": &nativeFunction{
+			arity: 1,
+			call: func(interpreter *Interpreter, paren token.Token, arguments []any) (any, error) {
+				ms, err := numberArgument(paren, "sleep", arguments, 0)
+				if err != nil {
+					return nil, err
+				}
+
+				if interpreter.ctx == nil {
+					time.Sleep(toDuration(ms))
+					return nil, nil
+				}
+
+				timer := time.NewTimer(toDuration(ms))
+				defer timer.Stop()
+				select {
+				case <-timer.C:
+					return nil, nil
+				case <-interpreter.ctx.Done():
+					return nil, interpreter.checkContext(paren)
+				}
+			},
+		},
+		// layouts use Go's reference time, see https://pkg.go.dev/time#pkg-constants
+		"format": &nativeFunction{
+			arity: 2,
+			call: func(interpreter *Interpreter, paren token.Token, arguments []any) (any, error) {
+				ms, err := numberArgument(paren, "format", arguments, 0)
+				if err != nil {
+					return nil, err
+				}
+
+				layout, err := stringArgument(paren, "format", arguments, 1)
+				if err != nil {
+					return nil, err
+				}
+
+				return time.UnixMilli(0).Add(toDuration(ms)).Format(layout), nil
+			},
+		},
+		"parse": &nativeFunction{
+			arity: 2,
+			call: func(interpreter *Interpreter, paren token.Token, arguments []any) (any, error) {
+				text, err := stringArgument(paren, "parse", arguments, 0)
+				if err != nil {
+					return nil, err
+				}
+
+				layout, err := stringArgument(paren, "parse", arguments, 1)
+				if err != nil {
+					return nil, err
+				}
+
+				parsed, err := time.Parse(layout, text)
+				if err != nil {
+					return nil, errors.NewRuntimeError(paren, fmt.Sprintf("failed to parse time: %v", err))
+				}
+
+				return toMilliseconds(parsed), nil
+			},
+		},
+		"formatDuration": &nativeFunction{
+			arity: 1,
+			call: func(interpreter *Interpreter, paren token.Token, arguments []any) (any, error) {
+				ms, err := numberArgument(paren, "formatDuration", arguments, 0)
+				if err != nil {
+					return nil, err
+				}
+
+				return toDuration(ms).String(), nil
+			},
+		},
+		"parseDuration": &nativeFunction{
+			arity: 1,
+			call: func(interpreter *Interpreter, paren token.Token, arguments []any) (any, error) {
+				text, err := stringArgument(paren, "parseDuration", arguments, 0)
+				if err != nil {
+					return nil, err
+				}
+
+				duration, err := time.ParseDuration(text)
+				if err != nil {
+					return nil, errors.NewRuntimeError(paren, fmt.Sprintf("failed to parse duration: %v", err))
+				}
+
+				return float64(duration) / float64(time.Millisecond), nil
+			},
+		},
+	}))
+}
+
+func toMilliseconds(t time.Time) float64 {
+	return float64(t.UnixNano()) / float64(time.Millisecond)
+}
+
+func toDuration(ms float64) time.Duration {
+	return time.Duration(ms * float64(time.Millisecond))
+}
@@ -0,0 +1,230 @@
+package linting
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/Drumstickz64/golox/ast"
+	"github.com/Drumstickz64/golox/interpreting"
+	"github.com/Drumstickz64/golox/parsing"
+	"github.com/Drumstickz64/golox/resolving"
+	"github.com/Drumstickz64/golox/scanning"
+	"github.com/Drumstickz64/golox/token"
+)
+
+const (
+	RULE_UNREACHABLE_CODE   = "unreachable-code"
+	RULE_CONSTANT_CONDITION = "constant-condition"
+)
+
+const ignoreDirective = "lox-lint: ignore"
+
+// every rule known to the linter, all of them are enabled by default
+var Rules = []string{
+	resolving.RULE_UNUSED_VARIABLE,
+	resolving.RULE_UNUSED_PARAMETER,
+	resolving.RULE_UNUSED_FUNCTION,
+	resolving.RULE_SHADOWED_NAME,
+	resolving.RULE_UNDEFINED_GLOBAL_ASSIGNMENT,
+	resolving.RULE_WRONG_ARITY,
+	RULE_UNREACHABLE_CODE,
+	RULE_CONSTANT_CONDITION,
+}
+
+type Config struct {
+	// rules that won't be reported
+	Disabled map[string]bool
+}
+
+// Lint returns the warnings found in source, sorted by position.
+// Warnings on the same line as a '// lox-lint: ignore' comment, or the line after it, are left out.
+// The comment can be followed by a comma separated list of rules, to only ignore those rules.
+// Returns the errors found while building the program if it's invalid
+func Lint(source string, config Config) ([]resolving.Warning, []error) {
+	scanner := scanning.NewScanner(source)
+	tokens, errs := scanner.ScanTokens()
+	if len(errs) > 0 {
+		return nil, errs
+	}
+
+	parser := parsing.NewParser(tokens)
+	statements, errs := parser.Parse()
+	if len(errs) > 0 {
+		return nil, errs
+	}
+
+	if parser.HadError {
+		return nil, []error{fmt.Errorf("failed to parse source")}
+	}
+
+	resolver := resolving.NewResolver(interpreting.NewInterpreter())
+	if hadError := resolver.Resolve(statements); hadError {
+		return nil, []error{fmt.Errorf("failed to resolve source")}
+	}
+
+	linter := newLinter(parser)
+	linter.lintStmts(statements)
+
+	ignored := ignoredLines(scanner.Comments())
+	warnings := []resolving.Warning{}
+	for _, warning := range slices.Concat(resolver.Warnings(), linter.warnings) {
+		if config.Disabled[warning.Rule] || isIgnored(ignored, warning) {
+			continue
+		}
+
+		warnings = append(warnings, warning)
+	}
+
+	slices.SortStableFunc(warnings, func(a, b resolving.Warning) int {
+		if a.Token.Line != b.Token.Line {
+			return a.Token.Line - b.Token.Line
+		}
+
+		return a.Token.Column - b.Token.Column
+	})
+
+	return warnings, nil
+}
+
+// checks the rules that depend on the shape of the program, rather than its scopes
+type linter struct {
+	spans    map[ast.Stmt]ast.Span
+	warnings []resolving.Warning
+	// 'for' keywords of while loops desugared from for loops
+	forKeywords map[*ast.WhileStmt]token.Token
+	// while loops desugared from for loops without a condition, whose condition is always true on purpose
+	infiniteLoops map[*ast.WhileStmt]bool
+}
+
+func newLinter(parser parsing.Parser) *linter {
+	l := &linter{
+		spans:         parser.Spans,
+		forKeywords:   map[*ast.WhileStmt]token.Token{},
+		infiniteLoops: map[*ast.WhileStmt]bool{},
+	}
+
+	for _, loop := range parser.ForLoops {
+		l.forKeywords[loop.While] = loop.Keyword
+		l.infiniteLoops[loop.While] = loop.Condition == nil
+	}
+
+	return l
+}
+
+func (l *linter) lintStmts(stmts []ast.Stmt) {
+	reportedUnreachable := false
+	for i, stmt := range stmts {
+		l.lintStmt(stmt)
+
+		if reportedUnreachable || i == len(stmts)-1 || !alwaysReturns(stmt) {
+			continue
+		}
+
+		// statements created when desugaring for loops aren't written by the user
+		if span, ok := l.spans[stmts[i+1]]; ok {
+			l.report(RULE_UNREACHABLE_CODE, span.Start, "code after 'return' is never executed")
+			reportedUnreachable = true
+		}
+	}
+}
+
+func (l *linter) lintStmt(stmt ast.Stmt) {
+	switch stmt := stmt.(type) {
+	case *ast.BlockStmt:
+		l.lintStmts(stmt.Statements)
+	case *ast.ClassStmt:
+		for _, method := range stmt.Methods {
+			l.lintStmts(method.Body)
+		}
+	case *ast.FunctionStmt:
+		l.lintStmts(stmt.Body)
+	case *ast.IfStmt:
+		l.lintCondition(stmt.Condition, l.spans[stmt].Start)
+		l.lintStmt(stmt.ThenBranch)
+		if stmt.ElseBranch != nil {
+			l.lintStmt(stmt.ElseBranch)
+		}
+	case *ast.WhileStmt:
+		keyword, isFor := l.forKeywords[stmt]
+		if !isFor {
+			keyword = l.spans[stmt].Start
+		}
+
+		if !l.infiniteLoops[stmt] {
+			l.lintCondition(stmt.Condition, keyword)
+		}
+		l.lintStmt(stmt.Body)
+	}
+}
+
+func (l *linter) lintCondition(condition ast.Expr, keyword token.Token) {
+	for {
+		grouping, ok := condition.(*ast.GroupingExpr)
+		if !ok {
+			break
+		}
+
+		condition = grouping.Expression
+	}
+
+	literal, ok := condition.(*ast.LiteralExpr)
+	if !ok || literal.Value == nil || literal.Value == false {
+		return
+	}
+
+	l.report(RULE_CONSTANT_CONDITION, keyword, fmt.Sprintf("condition of '%s' is always true", keyword.Lexeme))
+}
+
+func (l *linter) report(rule string, tok token.Token, msg string) {
+	l.warnings = append(l.warnings, resolving.Warning{
+		Rule:    rule,
+		Token:   tok,
+		Message: msg,
+	})
+}
+
+// whether executing stmt always ends with returning from the current function
+func alwaysReturns(stmt ast.Stmt) bool {
+	switch stmt := stmt.(type) {
+	case *ast.ReturnStmt:
+		return true
+	case *ast.BlockStmt:
+		return slices.ContainsFunc(stmt.Statements, alwaysReturns)
+	case *ast.IfStmt:
+		return stmt.ElseBranch != nil && alwaysReturns(stmt.ThenBranch) && alwaysReturns(stmt.ElseBranch)
+	}
+
+	return false
+}
+
+// maps the lines affected by ignore comments to the rules they ignore, a nil slice ignores every rule
+func ignoredLines(comments []token.Token) map[int][]string {
+	ignored := map[int][]string{}
+	for _, comment := range comments {
+		_, directive, found := strings.Cut(comment.Lexeme, ignoreDirective)
+		if !found {
+			continue
+		}
+
+		directive = strings.TrimSuffix(strings.TrimSpace(directive), "*/")
+		var rules []string
+		for _, rule := range strings.Split(directive, ",") {
+			if rule = strings.TrimSpace(rule); rule != "" {
+				rules = append(rules, rule)
+			}
+		}
+
+		// the comment applies to its own line, and the line after it
+		lastLine := comment.Line + strings.Count(comment.Lexeme, "\n")
+		ignored[lastLine] = rules
+		ignored[lastLine+1] = rules
+	}
+
+	return ignored
+}
+
+func isIgnored(ignored map[int][]string, warning resolving.Warning) bool {
+	rules, ok := ignored[warning.Token.Line]
+	return ok && (rules == nil || slices.Contains(rules, warning.Rule))
+}
@@ -0,0 +1,343 @@
+package lsp
+
+import (
+	goerrors "errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"github.com/Drumstickz64/golox/ast"
+	"github.com/Drumstickz64/golox/errors"
+	"github.com/Drumstickz64/golox/interpreting"
+	"github.com/Drumstickz64/golox/parsing"
+	"github.com/Drumstickz64/golox/resolving"
+	"github.com/Drumstickz64/golox/scanning"
+	"github.com/Drumstickz64/golox/token"
+)
+
+// an open document, analyzed every time its text changes
+type document struct {
+	uri        string
+	lines      []string
+	statements []ast.Stmt
+	spans      map[ast.Stmt]ast.Span
+	references []resolving.Reference
+	// natives, which are in scope everywhere
+	globals     []string
+	diagnostics []Diagnostic
+}
+
+func analyze(uri, text string) *document {
+	doc := &document{
+		uri:         uri,
+		lines:       strings.Split(text, "\n"),
+		spans:       map[ast.Stmt]ast.Span{},
+		diagnostics: []Diagnostic{},
+	}
+
+	interpreter := interpreting.NewInterpreter()
+	doc.globals = interpreter.GlobalNames()
+
+	scanner := scanning.NewScanner(text)
+	tokens, errs := scanner.ScanTokens()
+	if len(errs) > 0 {
+		doc.addDiagnostics(errs)
+		return doc
+	}
+
+	// the statements that were parsed are still resolved, so that navigation keeps working while typing
+	parser := parsing.NewParser(tokens)
+	statements, errs := parser.Parse()
+	doc.addDiagnostics(errs)
+	doc.statements = statements
+	doc.spans = parser.Spans
+
+	// errors are sent to the client as diagnostics instead
+	resolver := resolving.NewResolver(interpreter)
+	resolver.SetErrorOutput(io.Discard)
+	resolver.Resolve(statements)
+	doc.addDiagnostics(resolver.Errors())
+	doc.references = resolver.References()
+
+	return doc
+}
+
+func (d *document) addDiagnostics(errs []error) {
+	for _, err := range errs {
+		diagnostic := Diagnostic{
+			Severity: DIAGNOSTIC_SEVERITY_ERROR,
+			Source:   "golox",
+			Message:  err.Error(),
+		}
+
+		var buildtimeErr *errors.BuildtimeError
+		if goerrors.As(err, &buildtimeErr) {
+			// errors only know the column where they were found, so they cover a single character
+			start := Position{Line: buildtimeErr.Line - 1, Character: max(buildtimeErr.Column-1, 0)}
+			diagnostic.Range = Range{Start: start, End: Position{Line: start.Line, Character: start.Character + 1}}
+			diagnostic.Message = fmt.Sprint(buildtimeErr.Msg)
+			if buildtimeErr.Where != "" {
+				diagnostic.Message = strings.TrimSpace(buildtimeErr.Where) + ": " + diagnostic.Message
+			}
+		}
+
+		d.diagnostics = append(d.diagnostics, diagnostic)
+	}
+}
+
+// returns the declaration of the variable used or declared at pos
+func (d *document) declarationAt(pos Position) (token.Token, bool) {
+	for _, reference := range d.references {
+		if contains(reference.Use, pos) || contains(reference.Declaration, pos) {
+			return reference.Declaration, true
+		}
+	}
+
+	for _, declaration := range d.declarations() {
+		if contains(declaration, pos) {
+			return declaration, true
+		}
+	}
+
+	return token.Token{}, false
+}
+
+func (d *document) referencesAt(pos Position, includeDeclaration bool) []Location {
+	locations := []Location{}
+	declaration, ok := d.declarationAt(pos)
+	if !ok {
+		return locations
+	}
+
+	if includeDeclaration {
+		locations = append(locations, Location{Uri: d.uri, Range: tokenRange(declaration)})
+	}
+
+	for _, reference := range d.references {
+		if sameToken(reference.Declaration, declaration) {
+			locations = append(locations, Location{Uri: d.uri, Range: tokenRange(reference.Use)})
+		}
+	}
+
+	return locations
+}
+
+// shows the source line where the variable at pos is declared
+func (d *document) hoverAt(pos Position) (Hover, bool) {
+	declaration, ok := d.declarationAt(pos)
+	if !ok || declaration.Line > len(d.lines) {
+		return Hover{}, false
+	}
+
+	hovered := declaration
+	for _, reference := range d.references {
+		if contains(reference.Use, pos) {
+			hovered = reference.Use
+		}
+	}
+
+	line := strings.TrimSpace(d.lines[declaration.Line-1])
+	return Hover{
+		Contents: MarkupContent{
+			Kind:  "markdown",
+			Value: fmt.Sprintf("```lox\n%s\n```\ndeclared on line %d", line, declaration.Line),
+		},
+		Range: tokenRange(hovered),
+	}, true
+}
+
+// the classes, functions and global variables of the document, with methods nested in their classes
+func (d *document) symbols() []DocumentSymbol {
+	symbols := []DocumentSymbol{}
+	for _, stmt := range d.statements {
+		switch stmt := stmt.(type) {
+		case *ast.ClassStmt:
+			symbol := d.symbol(stmt, stmt.Name, SYMBOL_KIND_CLASS)
+			for _, method := range stmt.Methods {
+				symbol.Children = append(symbol.Children, d.symbol(method, method.Name, SYMBOL_KIND_METHOD))
+			}
+
+			symbols = append(symbols, symbol)
+		case *ast.FunctionStmt:
+			symbols = append(symbols, d.symbol(stmt, stmt.Name, SYMBOL_KIND_FUNCTION))
+		case *ast.VarStmt:
+			symbols = append(symbols, d.symbol(stmt, stmt.Name, SYMBOL_KIND_VARIABLE))
+		}
+	}
+
+	return symbols
+}
+
+func (d *document) symbol(stmt ast.Stmt, name token.Token, kind int) DocumentSymbol {
+	symbol := DocumentSymbol{
+		Name:           name.Lexeme,
+		Kind:           kind,
+		Range:          tokenRange(name),
+		SelectionRange: tokenRange(name),
+	}
+
+	if span, ok := d.spans[stmt]; ok {
+		symbol.Range = Range{Start: tokenRange(span.Start).Start, End: tokenRange(span.End).End}
+	}
+
+	return symbol
+}
+
+// keywords, natives, and the variables in scope at pos
+func (d *document) completionsAt(pos Position) []CompletionItem {
+	items := []CompletionItem{}
+	seen := map[string]bool{}
+	add := func(label string, kind int) {
+		if !seen[label] {
+			seen[label] = true
+			items = append(items, CompletionItem{Label: label, Kind: kind})
+		}
+	}
+
+	keywords := make([]string, 0, len(token.Keywords))
+	for keyword := range token.Keywords {
+		keywords = append(keywords, keyword)
+	}
+	slices.Sort(keywords)
+
+	// names in inner scopes come first, since they shadow the outer ones
+	scoped := d.namesInScope(d.statements, pos, true)
+	slices.Reverse(scoped)
+	for _, name := range scoped {
+		add(name.Lexeme, COMPLETION_ITEM_KIND_VARIABLE)
+	}
+
+	for _, name := range d.globals {
+		add(name, COMPLETION_ITEM_KIND_FUNCTION)
+	}
+
+	for _, keyword := range keywords {
+		add(keyword, COMPLETION_ITEM_KIND_KEYWORD)
+	}
+
+	return items
+}
+
+// returns the names declared in stmts and the scopes around pos, outer scopes first.
+// Globals can be used before they're declared, but locals are only in scope after their declaration
+func (d *document) namesInScope(stmts []ast.Stmt, pos Position, isGlobal bool) []token.Token {
+	names := []token.Token{}
+	declare := func(name token.Token) {
+		if isGlobal || isBeforePosition(name, pos) {
+			names = append(names, name)
+		}
+	}
+
+	for _, stmt := range stmts {
+		switch stmt := stmt.(type) {
+		case *ast.VarStmt:
+			declare(stmt.Name)
+		case *ast.ClassStmt:
+			declare(stmt.Name)
+			for _, method := range stmt.Methods {
+				if d.spanContains(method, pos) {
+					names = append(names, method.Parameters...)
+					names = append(names, d.namesInScope(method.Body, pos, false)...)
+				}
+			}
+		case *ast.FunctionStmt:
+			declare(stmt.Name)
+			if d.spanContains(stmt, pos) {
+				names = append(names, stmt.Parameters...)
+				names = append(names, d.namesInScope(stmt.Body, pos, false)...)
+			}
+		}
+
+		for _, child := range childScopes(stmt) {
+			if d.spanContains(child, pos) {
+				names = append(names, d.namesInScope(child.Statements, pos, false)...)
+			}
+		}
+	}
+
+	return names
+}
+
+// the blocks directly nested in a control flow statement, or the statement itself if it's a block
+func childScopes(stmt ast.Stmt) []*ast.BlockStmt {
+	switch stmt := stmt.(type) {
+	case *ast.BlockStmt:
+		return []*ast.BlockStmt{stmt}
+	case *ast.IfStmt:
+		return slices.Concat(childScopes(stmt.ThenBranch), childScopes(stmt.ElseBranch))
+	case *ast.WhileStmt:
+		return childScopes(stmt.Body)
+	}
+
+	return nil
+}
+
+// statements without a span, like the ones desugared from for loops, are assumed to contain every position
+func (d *document) spanContains(stmt ast.Stmt, pos Position) bool {
+	span, ok := d.spans[stmt]
+	if !ok {
+		return true
+	}
+
+	return isBeforePosition(span.Start, pos) && !isBeforePosition(span.End, pos)
+}
+
+// every name declared in the document, including the ones that are never used
+func (d *document) declarations() []token.Token {
+	declarations := []token.Token{}
+	var visit func(stmts []ast.Stmt)
+	visit = func(stmts []ast.Stmt) {
+		for _, stmt := range stmts {
+			switch stmt := stmt.(type) {
+			case *ast.VarStmt:
+				declarations = append(declarations, stmt.Name)
+			case *ast.ClassStmt:
+				declarations = append(declarations, stmt.Name)
+				for _, method := range stmt.Methods {
+					declarations = append(declarations, method.Parameters...)
+					visit(method.Body)
+				}
+			case *ast.FunctionStmt:
+				declarations = append(declarations, stmt.Name)
+				declarations = append(declarations, stmt.Parameters...)
+				visit(stmt.Body)
+			}
+
+			for _, child := range childScopes(stmt) {
+				visit(child.Statements)
+			}
+		}
+	}
+	visit(d.statements)
+
+	return declarations
+}
+
+// token columns are where the token ends, and lines start at 1
+func tokenRange(tok token.Token) Range {
+	return Range{
+		Start: Position{Line: tok.Line - 1, Character: max(tok.Column-len(tok.Lexeme), 0)},
+		End:   Position{Line: tok.Line - 1, Character: tok.Column},
+	}
+}
+
+// a position right after the token is included, since that's where the cursor is after typing it
+func contains(tok token.Token, pos Position) bool {
+	tokRange := tokenRange(tok)
+	return tokRange.Start.Line == pos.Line && tokRange.Start.Character <= pos.Character && pos.Character <= tokRange.End.Character
+}
+
+// whether the token ends before pos
+func isBeforePosition(tok token.Token, pos Position) bool {
+	tokRange := tokenRange(tok)
+	if tokRange.End.Line != pos.Line {
+		return tokRange.End.Line < pos.Line
+	}
+
+	return tokRange.End.Character <= pos.Character
+}
+
+func sameToken(a, b token.Token) bool {
+	return a.Line == b.Line && a.Column == b.Column && a.Lexeme == b.Lexeme
+}
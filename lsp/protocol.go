@@ -0,0 +1,211 @@
+package lsp
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// JSON-RPC error codes
+const (
+	ERROR_CODE_PARSE_ERROR            = -32700
+	ERROR_CODE_INVALID_REQUEST        = -32600
+	ERROR_CODE_METHOD_NOT_FOUND       = -32601
+	ERROR_CODE_INVALID_PARAMS         = -32602
+	ERROR_CODE_SERVER_NOT_INITIALIZED = -32002
+)
+
+const (
+	DIAGNOSTIC_SEVERITY_ERROR   = 1
+	DIAGNOSTIC_SEVERITY_WARNING = 2
+)
+
+const (
+	SYMBOL_KIND_CLASS    = 5
+	SYMBOL_KIND_METHOD   = 6
+	SYMBOL_KIND_FUNCTION = 12
+	SYMBOL_KIND_VARIABLE = 13
+)
+
+const (
+	COMPLETION_ITEM_KIND_FUNCTION = 3
+	COMPLETION_ITEM_KIND_VARIABLE = 6
+	COMPLETION_ITEM_KIND_KEYWORD  = 14
+)
+
+// documents are always sent whole on every change
+const TEXT_DOCUMENT_SYNC_KIND_FULL = 1
+
+// a request, a response or a notification, requests and responses have an id
+type Message struct {
+	Jsonrpc string           `json:"jsonrpc"`
+	Id      *json.RawMessage `json:"id,omitempty"`
+	Method  string           `json:"method,omitempty"`
+	Params  json.RawMessage  `json:"params,omitempty"`
+	// kept raw so that a null result is still sent
+	Result json.RawMessage `json:"result,omitempty"`
+	Error  *ResponseError  `json:"error,omitempty"`
+}
+
+type ResponseError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *ResponseError) Error() string {
+	return fmt.Sprintf("%s (%d)", e.Message, e.Code)
+}
+
+// lines and characters are zero based
+type Position struct {
+	Line      int `json:"line"`
+	Character int `json:"character"`
+}
+
+type Range struct {
+	Start Position `json:"start"`
+	End   Position `json:"end"`
+}
+
+type Location struct {
+	Uri   string `json:"uri"`
+	Range Range  `json:"range"`
+}
+
+type Diagnostic struct {
+	Range    Range  `json:"range"`
+	Severity int    `json:"severity"`
+	Source   string `json:"source"`
+	Message  string `json:"message"`
+}
+
+type DocumentSymbol struct {
+	Name           string           `json:"name"`
+	Kind           int              `json:"kind"`
+	Range          Range            `json:"range"`
+	SelectionRange Range            `json:"selectionRange"`
+	Children       []DocumentSymbol `json:"children,omitempty"`
+}
+
+type CompletionItem struct {
+	Label string `json:"label"`
+	Kind  int    `json:"kind"`
+}
+
+type MarkupContent struct {
+	Kind  string `json:"kind"`
+	Value string `json:"value"`
+}
+
+type Hover struct {
+	Contents MarkupContent `json:"contents"`
+	Range    Range         `json:"range"`
+}
+
+type PublishDiagnosticsParams struct {
+	Uri         string       `json:"uri"`
+	Diagnostics []Diagnostic `json:"diagnostics"`
+}
+
+type textDocumentIdentifier struct {
+	Uri string `json:"uri"`
+}
+
+type textDocumentItem struct {
+	Uri  string `json:"uri"`
+	Text string `json:"text"`
+}
+
+type didOpenParams struct {
+	TextDocument textDocumentItem `json:"textDocument"`
+}
+
+type didChangeParams struct {
+	TextDocument   textDocumentIdentifier `json:"textDocument"`
+	ContentChanges []struct {
+		Text string `json:"text"`
+	} `json:"contentChanges"`
+}
+
+type didCloseParams struct {
+	TextDocument textDocumentIdentifier `json:"textDocument"`
+}
+
+type textDocumentPositionParams struct {
+	TextDocument textDocumentIdentifier `json:"textDocument"`
+	Position     Position               `json:"position"`
+}
+
+type referenceParams struct {
+	textDocumentPositionParams
+	Context struct {
+		IncludeDeclaration bool `json:"includeDeclaration"`
+	} `json:"context"`
+}
+
+type documentSymbolParams struct {
+	TextDocument textDocumentIdentifier `json:"textDocument"`
+}
+
+// ReadMessage reads a message framed by a Content-Length header
+func ReadMessage(reader *bufio.Reader) (*Message, error) {
+	length := -1
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+
+		line = strings.TrimSpace(line)
+		if line == "" {
+			break
+		}
+
+		name, value, found := strings.Cut(line, ":")
+		if !found {
+			return nil, fmt.Errorf("invalid header '%s'", line)
+		}
+
+		if strings.EqualFold(strings.TrimSpace(name), "Content-Length") {
+			length, err = strconv.Atoi(strings.TrimSpace(value))
+			if err != nil || length < 0 {
+				return nil, fmt.Errorf("invalid content length '%s'", strings.TrimSpace(value))
+			}
+		}
+	}
+
+	if length < 0 {
+		return nil, fmt.Errorf("message is missing the Content-Length header")
+	}
+
+	content := make([]byte, length)
+	if _, err := io.ReadFull(reader, content); err != nil {
+		return nil, err
+	}
+
+	message := &Message{}
+	if err := json.Unmarshal(content, message); err != nil {
+		return nil, &ResponseError{Code: ERROR_CODE_PARSE_ERROR, Message: err.Error()}
+	}
+
+	return message, nil
+}
+
+// WriteMessage writes a message framed by a Content-Length header
+func WriteMessage(writer io.Writer, message *Message) error {
+	message.Jsonrpc = "2.0"
+	content, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+
+	if _, err := fmt.Fprintf(writer, "Content-Length: %d\r\n\r\n", len(content)); err != nil {
+		return err
+	}
+
+	_, err = writer.Write(content)
+	return err
+}
@@ -0,0 +1,259 @@
+package lsp
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// Server speaks the Language Server Protocol, reading requests from one stream and writing responses to another
+type Server struct {
+	reader    *bufio.Reader
+	writer    io.Writer
+	documents map[string]*document
+
+	isInitialized bool
+	isShutdown    bool
+}
+
+func NewServer(in io.Reader, out io.Writer) *Server {
+	return &Server{
+		reader:    bufio.NewReader(in),
+		writer:    out,
+		documents: map[string]*document{},
+	}
+}
+
+// Run handles messages until the client sends the exit notification or closes the input.
+// Returns an error if the client exits without shutting the server down first
+func (s *Server) Run() error {
+	for {
+		message, err := ReadMessage(s.reader)
+		if err == io.EOF {
+			return nil
+		}
+
+		if responseErr, ok := err.(*ResponseError); ok {
+			if err := s.respond(nil, nil, responseErr); err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		if err != nil {
+			return err
+		}
+
+		if message.Method == "exit" {
+			if !s.isShutdown {
+				return fmt.Errorf("received exit notification before shutdown")
+			}
+
+			return nil
+		}
+
+		if err := s.handle(message); err != nil {
+			return err
+		}
+	}
+}
+
+func (s *Server) handle(message *Message) error {
+	result, err := s.dispatch(message)
+	responseErr, isResponseErr := err.(*ResponseError)
+	if err != nil && !isResponseErr {
+		return err
+	}
+
+	// notifications don't get a response
+	if message.Id == nil {
+		return nil
+	}
+
+	return s.respond(message.Id, result, responseErr)
+}
+
+// returns a *ResponseError if the request failed, other errors stop the server
+func (s *Server) dispatch(message *Message) (any, error) {
+	if message.Method == "initialize" {
+		s.isInitialized = true
+		return map[string]any{
+			"capabilities": map[string]any{
+				"textDocumentSync":       TEXT_DOCUMENT_SYNC_KIND_FULL,
+				"definitionProvider":     true,
+				"referencesProvider":     true,
+				"hoverProvider":          true,
+				"documentSymbolProvider": true,
+				"completionProvider":     map[string]any{},
+			},
+			"serverInfo": map[string]any{"name": "golox"},
+		}, nil
+	}
+
+	if !s.isInitialized {
+		return nil, &ResponseError{Code: ERROR_CODE_SERVER_NOT_INITIALIZED, Message: "server is not initialized"}
+	}
+
+	switch message.Method {
+	case "initialized":
+		return nil, nil
+	case "shutdown":
+		s.isShutdown = true
+		return nil, nil
+	case "textDocument/didOpen":
+		params := didOpenParams{}
+		if err := decodeParams(message, &params); err != nil {
+			return nil, err
+		}
+
+		return nil, s.update(params.TextDocument.Uri, params.TextDocument.Text)
+	case "textDocument/didChange":
+		params := didChangeParams{}
+		if err := decodeParams(message, &params); err != nil {
+			return nil, err
+		}
+
+		if len(params.ContentChanges) == 0 {
+			return nil, nil
+		}
+
+		// with full sync, the last change holds the whole document
+		return nil, s.update(params.TextDocument.Uri, params.ContentChanges[len(params.ContentChanges)-1].Text)
+	case "textDocument/didClose":
+		params := didCloseParams{}
+		if err := decodeParams(message, &params); err != nil {
+			return nil, err
+		}
+
+		delete(s.documents, params.TextDocument.Uri)
+		return nil, s.publishDiagnostics(params.TextDocument.Uri, []Diagnostic{})
+	case "textDocument/definition":
+		params := textDocumentPositionParams{}
+		doc, err := s.findDocument(message, &params)
+		if err != nil {
+			return nil, err
+		}
+
+		declaration, ok := doc.declarationAt(params.Position)
+		if !ok {
+			return nil, nil
+		}
+
+		return Location{Uri: doc.uri, Range: tokenRange(declaration)}, nil
+	case "textDocument/references":
+		params := referenceParams{}
+		doc, err := s.findDocument(message, &params)
+		if err != nil {
+			return nil, err
+		}
+
+		return doc.referencesAt(params.Position, params.Context.IncludeDeclaration), nil
+	case "textDocument/hover":
+		params := textDocumentPositionParams{}
+		doc, err := s.findDocument(message, &params)
+		if err != nil {
+			return nil, err
+		}
+
+		hover, ok := doc.hoverAt(params.Position)
+		if !ok {
+			return nil, nil
+		}
+
+		return hover, nil
+	case "textDocument/documentSymbol":
+		params := documentSymbolParams{}
+		if err := decodeParams(message, &params); err != nil {
+			return nil, err
+		}
+
+		doc, ok := s.documents[params.TextDocument.Uri]
+		if !ok {
+			return []DocumentSymbol{}, nil
+		}
+
+		return doc.symbols(), nil
+	case "textDocument/completion":
+		params := textDocumentPositionParams{}
+		doc, err := s.findDocument(message, &params)
+		if err != nil {
+			return nil, err
+		}
+
+		return doc.completionsAt(params.Position), nil
+	}
+
+	return nil, &ResponseError{Code: ERROR_CODE_METHOD_NOT_FOUND, Message: fmt.Sprintf("method '%s' is not supported", message.Method)}
+}
+
+// decodes the params of a request about a position in a document, and returns the document
+func (s *Server) findDocument(message *Message, params positionParams) (*document, *ResponseError) {
+	if err := decodeParams(message, params); err != nil {
+		return nil, err
+	}
+
+	uri := params.uri()
+	doc, ok := s.documents[uri]
+	if !ok {
+		return nil, &ResponseError{Code: ERROR_CODE_INVALID_PARAMS, Message: fmt.Sprintf("document '%s' is not open", uri)}
+	}
+
+	return doc, nil
+}
+
+type positionParams interface {
+	uri() string
+}
+
+func (p *textDocumentPositionParams) uri() string {
+	return p.TextDocument.Uri
+}
+
+func decodeParams(message *Message, params any) *ResponseError {
+	if err := json.Unmarshal(message.Params, params); err != nil {
+		return &ResponseError{Code: ERROR_CODE_INVALID_PARAMS, Message: err.Error()}
+	}
+
+	return nil
+}
+
+// analyzes the new text of a document and publishes its diagnostics
+func (s *Server) update(uri, text string) error {
+	doc := analyze(uri, text)
+	s.documents[uri] = doc
+	return s.publishDiagnostics(uri, doc.diagnostics)
+}
+
+func (s *Server) publishDiagnostics(uri string, diagnostics []Diagnostic) error {
+	params, err := json.Marshal(PublishDiagnosticsParams{Uri: uri, Diagnostics: diagnostics})
+	if err != nil {
+		return err
+	}
+
+	return WriteMessage(s.writer, &Message{
+		Method: "textDocument/publishDiagnostics",
+		Params: params,
+	})
+}
+
+func (s *Server) respond(id *json.RawMessage, result any, responseErr *ResponseError) error {
+	// requests whose id couldn't be read are answered with a null id
+	if id == nil {
+		null := json.RawMessage("null")
+		id = &null
+	}
+
+	response := &Message{Id: id, Error: responseErr}
+	if responseErr == nil {
+		encoded, err := json.Marshal(result)
+		if err != nil {
+			return err
+		}
+
+		response.Result = encoded
+	}
+
+	return WriteMessage(s.writer, response)
+}
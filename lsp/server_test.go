@@ -0,0 +1,286 @@
+package lsp
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+const TEST_URI = "file:///test.lox"
+
+const TEST_SOURCE = `var count = 1;
+fun add(n) {
+  return count + n;
+}
+print add(2);
+`
+
+// talks to a server running in the same process, through pipes
+type testClient struct {
+	t        *testing.T
+	toServer *io.PipeWriter
+	messages chan *Message
+	// the result of the server's Run
+	done   chan error
+	nextId int
+}
+
+func newTestClient(t *testing.T) *testClient {
+	serverIn, toServer := io.Pipe()
+	fromServer, serverOut := io.Pipe()
+	c := &testClient{
+		t:        t,
+		toServer: toServer,
+		messages: make(chan *Message, 16),
+		done:     make(chan error, 1),
+	}
+
+	go func() {
+		c.done <- NewServer(serverIn, serverOut).Run()
+		serverOut.Close()
+	}()
+
+	go func() {
+		reader := bufio.NewReader(fromServer)
+		for {
+			message, err := ReadMessage(reader)
+			if err != nil {
+				close(c.messages)
+				return
+			}
+
+			c.messages <- message
+		}
+	}()
+
+	t.Cleanup(func() { toServer.Close() })
+	return c
+}
+
+func (c *testClient) send(message *Message) {
+	c.t.Helper()
+	if err := WriteMessage(c.toServer, message); err != nil {
+		c.t.Fatal(err)
+	}
+}
+
+func (c *testClient) notify(method string, params any) {
+	c.t.Helper()
+	c.send(&Message{Method: method, Params: encode(c.t, params)})
+}
+
+// sends a request and waits for its response, decoding its result into result
+func (c *testClient) request(method string, params any, result any) {
+	c.t.Helper()
+	c.nextId++
+	id := json.RawMessage(strconv.Itoa(c.nextId))
+	c.send(&Message{Id: &id, Method: method, Params: encode(c.t, params)})
+
+	for {
+		message := c.receive()
+		if message.Id == nil || string(*message.Id) != string(id) {
+			continue
+		}
+
+		if message.Error != nil {
+			c.t.Fatalf("%s failed: %v", method, message.Error)
+		}
+
+		if result != nil {
+			if err := json.Unmarshal(message.Result, result); err != nil {
+				c.t.Fatal(err)
+			}
+		}
+
+		return
+	}
+}
+
+// waits for the diagnostics the server publishes for a document
+func (c *testClient) diagnostics() []Diagnostic {
+	c.t.Helper()
+	for {
+		message := c.receive()
+		if message.Method != "textDocument/publishDiagnostics" {
+			continue
+		}
+
+		params := PublishDiagnosticsParams{}
+		if err := json.Unmarshal(message.Params, &params); err != nil {
+			c.t.Fatal(err)
+		}
+
+		return params.Diagnostics
+	}
+}
+
+func (c *testClient) receive() *Message {
+	c.t.Helper()
+	select {
+	case message, ok := <-c.messages:
+		if !ok {
+			c.t.Fatal("the server closed its output")
+		}
+
+		return message
+	case <-time.After(5 * time.Second):
+		c.t.Fatal("timed out waiting for the server")
+	}
+
+	return nil
+}
+
+func (c *testClient) open(text string) []Diagnostic {
+	c.t.Helper()
+	c.notify("textDocument/didOpen", map[string]any{
+		"textDocument": map[string]any{"uri": TEST_URI, "languageId": "lox", "version": 1, "text": text},
+	})
+
+	return c.diagnostics()
+}
+
+func encode(t *testing.T, value any) json.RawMessage {
+	t.Helper()
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return encoded
+}
+
+func atPosition(line, character int) map[string]any {
+	return map[string]any{
+		"textDocument": map[string]any{"uri": TEST_URI},
+		"position":     Position{Line: line, Character: character},
+	}
+}
+
+// creates a client for an initialized server with TEST_SOURCE open
+func newInitializedClient(t *testing.T) *testClient {
+	c := newTestClient(t)
+	c.request("initialize", map[string]any{"capabilities": map[string]any{}}, nil)
+	c.notify("initialized", map[string]any{})
+	if diagnostics := c.open(TEST_SOURCE); len(diagnostics) != 0 {
+		t.Fatalf("expected no diagnostics, got %v", diagnostics)
+	}
+
+	return c
+}
+
+func TestInitialize(t *testing.T) {
+	c := newTestClient(t)
+	result := struct {
+		Capabilities map[string]any `json:"capabilities"`
+		ServerInfo   struct {
+			Name string `json:"name"`
+		} `json:"serverInfo"`
+	}{}
+	c.request("initialize", map[string]any{"capabilities": map[string]any{}}, &result)
+
+	if result.ServerInfo.Name != "golox" {
+		t.Errorf("expected the server to be named golox, got %q", result.ServerInfo.Name)
+	}
+
+	for _, capability := range []string{"definitionProvider", "referencesProvider", "hoverProvider", "completionProvider"} {
+		if _, ok := result.Capabilities[capability]; !ok {
+			t.Errorf("expected the %s capability", capability)
+		}
+	}
+
+	c.request("shutdown", nil, nil)
+	c.notify("exit", nil)
+	select {
+	case err := <-c.done:
+		if err != nil {
+			t.Errorf("expected the server to exit cleanly, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for the server to exit")
+	}
+}
+
+func TestDidOpenDiagnostics(t *testing.T) {
+	c := newInitializedClient(t)
+	diagnostics := c.open("var = 1;\n{ var a = a; }\n")
+	if len(diagnostics) != 2 {
+		t.Fatalf("expected a parse and a resolve error, got %v", diagnostics)
+	}
+
+	for _, diagnostic := range diagnostics {
+		if diagnostic.Severity != DIAGNOSTIC_SEVERITY_ERROR {
+			t.Errorf("expected an error, got severity %d", diagnostic.Severity)
+		}
+	}
+
+	if line := diagnostics[0].Range.Start.Line; line != 0 {
+		t.Errorf("expected the parse error on line 0, got %d", line)
+	}
+
+	if line := diagnostics[1].Range.Start.Line; line != 1 {
+		t.Errorf("expected the resolve error on line 1, got %d", line)
+	}
+}
+
+func TestDefinition(t *testing.T) {
+	c := newInitializedClient(t)
+	location := Location{}
+	// 'add' in 'print add(2);'
+	c.request("textDocument/definition", atPosition(4, 7), &location)
+
+	expected := Range{Start: Position{Line: 1, Character: 4}, End: Position{Line: 1, Character: 7}}
+	if location.Uri != TEST_URI || location.Range != expected {
+		t.Errorf("expected the declaration of 'add' at %v, got %v", expected, location)
+	}
+}
+
+func TestReferences(t *testing.T) {
+	c := newInitializedClient(t)
+	params := atPosition(2, 10)
+	params["context"] = map[string]any{"includeDeclaration": true}
+	locations := []Location{}
+	// 'count' in 'return count + n;'
+	c.request("textDocument/references", params, &locations)
+
+	lines := []int{}
+	for _, location := range locations {
+		lines = append(lines, location.Range.Start.Line)
+	}
+
+	if !slices.Equal(lines, []int{0, 2}) {
+		t.Errorf("expected the declaration and use of 'count' on lines 0 and 2, got %v", locations)
+	}
+}
+
+func TestHover(t *testing.T) {
+	c := newInitializedClient(t)
+	hover := Hover{}
+	c.request("textDocument/hover", atPosition(4, 7), &hover)
+
+	if !strings.Contains(hover.Contents.Value, "fun add(n) {") || !strings.Contains(hover.Contents.Value, "declared on line 2") {
+		t.Errorf("expected the declaration of 'add', got %q", hover.Contents.Value)
+	}
+}
+
+func TestCompletion(t *testing.T) {
+	c := newInitializedClient(t)
+	items := []CompletionItem{}
+	// inside the body of 'add'
+	c.request("textDocument/completion", atPosition(2, 17), &items)
+
+	labels := map[string]bool{}
+	for _, item := range items {
+		labels[item.Label] = true
+	}
+
+	for _, expected := range []string{"n", "count", "add", "clock", "return"} {
+		if !labels[expected] {
+			t.Errorf("expected %q to be completed", expected)
+		}
+	}
+}
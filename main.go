@@ -2,127 +2,455 @@ package main
 
 import (
 	"bufio"
+	"context"
 	goerrors "errors"
+	"flag"
 	"fmt"
 	"io"
+	"net"
 	"os"
+	"path/filepath"
+	"slices"
 	"strings"
 
+	"github.com/Drumstickz64/golox/assert"
 	"github.com/Drumstickz64/golox/ast"
+	"github.com/Drumstickz64/golox/conformance"
+	"github.com/Drumstickz64/golox/coverage"
+	"github.com/Drumstickz64/golox/debugging"
+	"github.com/Drumstickz64/golox/editing"
 	"github.com/Drumstickz64/golox/errors"
+	"github.com/Drumstickz64/golox/formatting"
 	"github.com/Drumstickz64/golox/interpreting"
+	"github.com/Drumstickz64/golox/linting"
+	"github.com/Drumstickz64/golox/lsp"
 	"github.com/Drumstickz64/golox/parsing"
+	"github.com/Drumstickz64/golox/profiling"
+	"github.com/Drumstickz64/golox/repl"
 	"github.com/Drumstickz64/golox/resolving"
 	"github.com/Drumstickz64/golox/scanning"
+	"github.com/Drumstickz64/golox/testrunner"
+	"github.com/Drumstickz64/golox/tracing"
 )
 
+var (
+	sandbox       = flag.Bool("sandbox", false, "disable natives that run other processes, like os.exec")
+	showPositions = flag.Bool("positions", false, "show token positions in the output of parse")
+	showDepths    = flag.Bool("depths", false, "show resolved scope depths of variables in the output of parse")
+	jsonAst       = flag.Bool("json", false, "use the JSON representation of the AST, ast outputs it and run loads it instead of source code")
+	checkFormat   = flag.Bool("check", false, "make fmt list the files that aren't formatted and fail instead of printing them")
+	writeFormat   = flag.Bool("w", false, "make fmt write the formatted source back to the files instead of printing it")
+	enabledRules  = flag.String("enable", "", "comma separated list of the only rules reported by lint")
+	disabledRules = flag.String("disable", "", "comma separated list of rules that aren't reported by lint")
+	tracePath     = flag.String("trace", "", "make run log every executed statement, call and assignment to this file")
+	traceFormat   = flag.String("trace-format", "text", "format of the trace written by --trace, either text or json")
+	profilePath   = flag.String("profile", "", "make run write a pprof profile of the time spent in each function and line to this file, and print a summary")
+	profileTop    = flag.Int("profile-top", 10, "number of functions and lines in the summary printed by --profile")
+	coveragePath  = flag.String("coverage", "", "make run write how many times each statement ran to this file, in the format of Go's cover profiles")
+	coverageHtml  = flag.String("coverage-html", "", "make run write an HTML page showing the coverage of the source to this file")
+	coverageLcov  = flag.String("coverage-lcov", "", "make run write the line and branch coverage to this file in the LCOV format")
+	testFormat    = flag.String("test-format", "text", "format of the results printed by test, either text, tap or junit")
+	maxSteps      = flag.Int("max-steps", 0, "stop the script with an error after it executes this many statements, 0 for no limit")
+	maxCallDepth  = flag.Int("max-call-depth", interpreting.DEFAULT_MAX_CALL_DEPTH, "stop the script with an error when it nests more calls than this, 0 for no limit, which lets deep recursion crash the interpreter")
+	maxMemory     = flag.Int("max-memory", 0, "stop the script with an error after it allocates about this many bytes, 0 for no limit")
+	timeout       = flag.Duration("timeout", 0, "stop the script with an error after it runs for this long, like 500ms or 2s, 0 for no limit")
+	dapAddress    = flag.String("dap", "", "make debug wait for a Debug Adapter Protocol client on this TCP address instead of reading commands from the terminal")
+	allowRead     = PathsFlagVar("allow-read", "let the script read these comma separated files and directories, or any path without a value. Passing any --allow flag denies the script everything that isn't allowed")
+	allowWrite    = PathsFlagVar("allow-write", "let the script write, create and remove these comma separated files and directories, or any path without a value")
+	allowEnv      = flag.Bool("allow-env", false, "let the script get and set environment variables")
+	allowExec     = flag.Bool("allow-exec", false, "let the script run other processes, unless --sandbox is passed")
+	strictAsserts = flag.Bool("strict-asserts", false, "crash with a panic when an internal assertion fails, instead of reporting an internal error")
+)
+
+// a flag granting access to a comma separated list of paths, or to every path when it's passed without a value
+type PathsFlag struct {
+	set   bool
+	all   bool
+	paths []string
+}
+
+func PathsFlagVar(name, usage string) *PathsFlag {
+	paths := &PathsFlag{}
+	flag.Var(paths, name, usage)
+	return paths
+}
+
+func (f *PathsFlag) String() string {
+	if f.all {
+		return "true"
+	}
+
+	return strings.Join(f.paths, ",")
+}
+
+func (f *PathsFlag) Set(value string) error {
+	f.set = true
+	if value == "true" {
+		f.all = true
+		return nil
+	}
+
+	for _, pth := range strings.Split(value, ",") {
+		if pth != "" {
+			f.paths = append(f.paths, pth)
+		}
+	}
+
+	return nil
+}
+
+// lets the flag be passed without a value, like a boolean flag
+func (f *PathsFlag) IsBoolFlag() bool {
+	return true
+}
+
 func main() {
-	if len(os.Args) == 1 {
+	flag.Usage = LogUsageMessage
+	args, scriptArgs := SplitScriptArgs(os.Args[1:])
+	args = ParseFlags(args)
+	assert.SetStrict(*strictAsserts)
+	if len(args) > 0 {
+		switch args[0] {
+		case "fmt":
+			FormatFiles(args[1:])
+			return
+		case "lint":
+			LintFiles(args[1:])
+			return
+		case "lsp":
+			ServeLsp(args[1:])
+			return
+		case "debug":
+			DebugFile(args[1:], scriptArgs)
+			return
+		case "test":
+			TestFiles(args[1:])
+			return
+		case "conformance":
+			CheckConformance(args[1:])
+			return
+		}
+	}
+
+	if len(args) == 0 {
+		if len(scriptArgs) > 0 {
+			LogUsageMessage()
+		}
+
 		RunPrompt()
-	} else if len(os.Args) == 2 {
-		RunFile(os.Args[1])
-	} else if len(os.Args) == 3 {
-		switch os.Args[2] {
+	} else if len(args) == 1 {
+		RunFile(args[0], scriptArgs)
+	} else if len(args) == 2 {
+		switch args[1] {
 		case "scan":
-			TestScanning(os.Args[1])
+			TestScanning(args[0])
 		case "parse":
-			TestParsing(os.Args[1])
+			TestParsing(args[0])
+		case "ast":
+			PrintAst(args[0])
 		case "run":
-			RunFile(os.Args[1])
+			RunFile(args[0], scriptArgs)
 		default:
-			errors.LogUsageMessage()
+			LogUsageMessage()
 		}
 	} else {
-		errors.LogUsageMessage()
+		LogUsageMessage()
 	}
 }
 
-func RunFile(path string) {
-	source := LoadSource(path)
+// splits the command-line arguments at the first "--", everything after it is passed to the script
+func SplitScriptArgs(args []string) ([]string, []string) {
+	for i, arg := range args {
+		if arg == "--" {
+			return args[:i], args[i+1:]
+		}
+	}
+
+	return args, nil
+}
+
+// parses flags mixed in between the positional arguments, and returns the positional arguments
+func ParseFlags(args []string) []string {
+	positional := []string{}
+	for {
+		// flag parsing stops at the first positional argument, so we continue after it
+		flag.CommandLine.Parse(args)
+		args = flag.Args()
+		if len(args) == 0 {
+			return positional
+		}
+
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
+
+func LogUsageMessage() {
+	fmt.Fprintln(os.Stderr, "Usage: golox [flags] [script [scan|parse|ast|run]] [-- args...]")
+	fmt.Fprintln(os.Stderr, "       golox [flags] fmt files...")
+	fmt.Fprintln(os.Stderr, "       golox [flags] lint files...")
+	fmt.Fprintln(os.Stderr, "       golox lsp")
+	fmt.Fprintln(os.Stderr, "       golox [flags] debug script [-- args...]")
+	fmt.Fprintln(os.Stderr, "       golox [flags] test files or directories...")
+	fmt.Fprintln(os.Stderr, "       golox conformance [directories...]")
+	flag.PrintDefaults()
+	os.Exit(64)
+}
+
+// NewInterpreter creates an interpreter configured by the command-line flags
+func NewInterpreter() *interpreting.Interpreter {
 	interpreter := interpreting.NewInterpreter()
+	// scripts can do anything unless they're given specific permissions
+	permissions := interpreting.AllPermissions()
+	if allowRead.set || allowWrite.set || *allowEnv || *allowExec {
+		permissions = interpreting.Permissions{
+			Read:     allowRead.paths,
+			ReadAll:  allowRead.all,
+			Write:    allowWrite.paths,
+			WriteAll: allowWrite.all,
+			Env:      *allowEnv,
+			Exec:     *allowExec,
+		}
+	}
+
+	permissions.Exec = permissions.Exec && !*sandbox
+	if err := interpreter.SetPermissions(permissions); err != nil {
+		errors.LogCliError(fmt.Sprintf("invalid permissions: %v", err), 64)
+	}
+
+	interpreter.SetLimits(interpreting.Limits{
+		MaxSteps:     *maxSteps,
+		MaxCallDepth: *maxCallDepth,
+		MaxMemory:    *maxMemory,
+	})
+	return interpreter
+}
+
+func RunFile(path string, args []string) {
+	source := LoadSource(path)
+	interpreter := NewInterpreter()
+	interpreter.SetArgs(args)
 	resolver := resolving.NewResolver(interpreter)
 
-	statements, errs := Build(source)
+	statements, parser, errs := LoadProgram(source)
 	for _, err := range errs {
-		fmt.Fprintln(os.Stderr, err)
+		LogError(err)
 	}
 
 	if len(errs) > 0 {
 		os.Exit(65)
 	}
 
-	if err := Run(resolver, interpreter, statements); err != nil {
-		fmt.Fprintln(os.Stderr, err)
+	// observers are finished before exiting, so their output is kept when the script fails
+	finishers := []func(){}
+	if *tracePath != "" {
+		tracer, closeTrace := NewTracer(source, parser)
+		interpreter.AddObserver(tracer)
+		finishers = append(finishers, closeTrace)
+	}
+
+	if *profilePath != "" {
+		profiler := profiling.NewProfiler(path, parser)
+		interpreter.AddObserver(profiler)
+		finishers = append(finishers, func() { WriteProfile(profiler) })
+	}
+
+	if *coveragePath != "" || *coverageHtml != "" || *coverageLcov != "" {
+		recorder := coverage.NewRecorder()
+		interpreter.AddObserver(recorder)
+		finishers = append(finishers, func() {
+			WriteCoverage(recorder.Report(path, source, statements, parser))
+		})
+	}
+
+	err := Run(resolver, interpreter, statements)
+	for _, finish := range finishers {
+		finish()
+	}
+
+	if err == errResolving {
+		os.Exit(65)
+	}
+
+	if err != nil {
+		ExitIfRequested(err)
+		LogError(err)
 		os.Exit(70)
 	}
 }
 
-func LoadSource(path string) string {
-	source, err := os.ReadFile(path)
+// writes the coverage reports requested by the coverage flags, and prints a summary
+func WriteCoverage(report coverage.Report) {
+	reports := []struct {
+		path  string
+		write func(w io.Writer) error
+	}{
+		{*coveragePath, report.WriteProfile},
+		{*coverageHtml, report.WriteHtml},
+		{*coverageLcov, report.WriteLcov},
+	}
+
+	for _, r := range reports {
+		if r.path == "" {
+			continue
+		}
+
+		file, err := os.Create(r.path)
+		if err != nil {
+			errors.LogCliError(err, 73)
+		}
+
+		if err := goerrors.Join(r.write(file), file.Close()); err != nil {
+			errors.LogCliError(err, 74)
+		}
+	}
+
+	fmt.Fprintln(os.Stderr, "coverage:", report.SummaryString())
+}
+
+// writes the profile to the file requested by the profile flag, and prints its summary
+func WriteProfile(profiler *profiling.Profiler) {
+	profiler.Finish()
+
+	file, err := os.Create(*profilePath)
 	if err != nil {
-		errors.LogCliError(err, 66)
+		errors.LogCliError(err, 73)
 	}
 
-	return string(source)
+	if err := goerrors.Join(profiler.WritePprof(file), file.Close()); err != nil {
+		errors.LogCliError(err, 74)
+	}
+
+	if err := profiler.WriteSummary(os.Stderr, *profileTop); err != nil {
+		errors.LogCliError(err, 74)
+	}
 }
 
-func RunPrompt() {
-	reader := bufio.NewReader(os.Stdin)
-	interpreter := interpreting.NewInterpreter()
-	resolver := resolving.NewResolver(interpreter)
+// creates the tracer requested by the trace flags, along with a function that finishes writing the trace
+func NewTracer(source string, parser parsing.Parser) (*tracing.Tracer, func()) {
+	format, ok := tracing.ParseFormat(*traceFormat)
+	if !ok {
+		errors.LogCliError(fmt.Sprintf("unknown trace format '%s', expected text or json", *traceFormat), 64)
+	}
 
-PromptLoop:
-	for {
-		fmt.Print("> ")
-		line, err := reader.ReadString('\n')
-		if err != nil {
-			if goerrors.Is(err, io.EOF) {
-				break
-			}
+	file, err := os.Create(*tracePath)
+	if err != nil {
+		errors.LogCliError(err, 73)
+	}
 
+	writer := bufio.NewWriter(file)
+	tracer := tracing.NewTracer(writer, format, source, parser)
+	closeTrace := func() {
+		err := goerrors.Join(tracer.Err(), writer.Flush(), file.Close())
+		if err != nil {
 			errors.LogCliError(err, 74)
 		}
+	}
 
-		line = strings.TrimSpace(line)
+	return tracer, closeTrace
+}
 
-		if line == "" {
-			continue PromptLoop
-		}
+// exits the process if err was caused by the script calling the 'exit' native
+func ExitIfRequested(err error) {
+	var exitErr *interpreting.ExitError
+	if goerrors.As(err, &exitErr) {
+		os.Exit(exitErr.Code)
+	}
+}
 
-		statements, errs := Build(line)
-		for _, err := range errs {
-			fmt.Fprintln(os.Stderr, err)
-			continue PromptLoop
-		}
+// prints err, along with the Go stack of internal errors, which are bugs in golox
+func LogError(err error) {
+	fmt.Fprintln(os.Stderr, err)
+	var internalErr *errors.InternalError
+	if goerrors.As(err, &internalErr) {
+		fmt.Fprintf(os.Stderr, "this is a bug in golox, please report it along with the stack:\n%s", internalErr.Stack)
+	}
+}
+
+func LoadSource(path string) string {
+	source, err := os.ReadFile(path)
+	if err != nil {
+		errors.LogCliError(err, 66)
+	}
 
-		if err := Run(resolver, interpreter, statements); err != nil {
-			fmt.Fprintln(os.Stderr, err)
+	return string(source)
+}
+
+// the file in the home directory the history of the REPL is saved to
+const HISTORY_FILE = ".golox_history"
+
+func RunPrompt() {
+	editor := editing.NewEditor(os.Stdin, os.Stdout)
+	if home, err := os.UserHomeDir(); err == nil {
+		if err := editor.LoadHistory(filepath.Join(home, HISTORY_FILE)); err != nil {
+			fmt.Fprintln(os.Stderr, "golox: failed to load history:", err)
 		}
 	}
+
+	if err := repl.New(editor, NewInterpreter, os.Stdout, os.Stderr).Run(); err != nil {
+		ExitIfRequested(err)
+		errors.LogCliError(err, 74)
+	}
 }
 
 func Build(source string) ([]ast.Stmt, []error) {
+	statements, _, errs := BuildWithParser(source)
+	return statements, errs
+}
+
+// like Build, but also returns the parser, whose side tables map statements back to the source
+func BuildWithParser(source string) ([]ast.Stmt, parsing.Parser, []error) {
 	scanner := scanning.NewScanner(source)
 	tokens, errs := scanner.ScanTokens()
 	if len(errs) > 0 {
-		return nil, errs
+		return nil, parsing.Parser{}, errs
 	}
 	parser := parsing.NewParser(tokens)
 	statements, errs := parser.Parse()
 
 	if len(errs) > 0 || parser.HadError {
-		return nil, errs
+		return nil, parser, errs
 	}
 
-	return statements, errs
+	return statements, parser, errs
+}
+
+// builds the program from source code, or loads it from its JSON representation if the json flag is set.
+// The zero parser is returned for JSON, since it doesn't know where the statements are in the source
+func LoadProgram(source string) ([]ast.Stmt, parsing.Parser, []error) {
+	if !*jsonAst {
+		return BuildWithParser(source)
+	}
+
+	statements, err := ast.ProgramFromJson([]byte(source))
+	if err != nil {
+		return nil, parsing.Parser{}, []error{fmt.Errorf("golox: invalid AST JSON: %w", err)}
+	}
+
+	return statements, parsing.Parser{}, nil
 }
 
+// returned by Run when the program fails to resolve, the resolver has already reported why
+var errResolving = goerrors.New("failed to resolve the program")
+
 func Run(resolver *resolving.Resolver, interpreter *interpreting.Interpreter, statements []ast.Stmt) error {
 	if hadError := resolver.Resolve(statements); hadError {
-		return nil
+		return errResolving
 	}
 
-	return interpreter.Interpret(statements)
+	return Interpret(interpreter, statements)
+}
+
+// runs statements that were already resolved, stopping them once the timeout flag's duration has passed
+func Interpret(interpreter *interpreting.Interpreter, statements []ast.Stmt) error {
+	ctx := context.Background()
+	if *timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, *timeout)
+		defer cancel()
+	}
+
+	return interpreter.InterpretContext(ctx, statements)
 }
 
 func TestScanning(pth string) {
@@ -145,25 +473,303 @@ func TestScanning(pth string) {
 }
 
 func TestParsing(pth string) {
-	panic("Parsing test currently unavailable")
-	// source := LoadSource(pth)
-
-	// scanner := scanning.NewScanner(source)
-	// tokens, errs := scanner.ScanTokens()
-	// for _, err := range errs {
-	// 	fmt.Fprintln(os.Stderr, err)
-	// }
-
-	// if len(errs) > 0 {
-	// 	os.Exit(65)
-	// }
-
-	// parser := parsing.NewParser(tokens)
-	// expression, err := parser.Parse()
-	// if err != nil {
-	// 	fmt.Fprintln(os.Stderr, err)
-	// 	os.Exit(65)
-	// }
-
-	// fmt.Println(ast.NewPrinter().Print(expression))
+	source := LoadSource(pth)
+
+	statements, errs := Build(source)
+	for _, err := range errs {
+		fmt.Fprintln(os.Stderr, err)
+	}
+
+	if len(errs) > 0 {
+		os.Exit(65)
+	}
+
+	printer := ast.NewPrinter()
+	printer.ShowPositions = *showPositions
+	if *showDepths {
+		interpreter := NewInterpreter()
+		if hadError := resolving.NewResolver(interpreter).Resolve(statements); hadError {
+			os.Exit(65)
+		}
+
+		printer.Depths = interpreter.Depth
+	}
+
+	output, err := printer.PrintProgram(statements)
+	if err != nil {
+		errors.LogCliError(err, 70)
+	}
+
+	fmt.Println(output)
+}
+
+func PrintAst(pth string) {
+	if !*jsonAst {
+		TestParsing(pth)
+		return
+	}
+
+	source := LoadSource(pth)
+
+	statements, errs := Build(source)
+	for _, err := range errs {
+		fmt.Fprintln(os.Stderr, err)
+	}
+
+	if len(errs) > 0 {
+		os.Exit(65)
+	}
+
+	output, err := ast.ProgramToJson(statements)
+	if err != nil {
+		errors.LogCliError(err, 70)
+	}
+
+	fmt.Println(string(output))
+}
+
+func FormatFiles(paths []string) {
+	if len(paths) == 0 {
+		LogUsageMessage()
+	}
+
+	hadError := false
+	unformatted := false
+	for _, pth := range paths {
+		source := LoadSource(pth)
+		formatted, errs := formatting.Format(source)
+		for _, err := range errs {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", pth, err)
+		}
+
+		if len(errs) > 0 {
+			hadError = true
+			continue
+		}
+
+		if *checkFormat {
+			if formatted != source {
+				fmt.Println(pth)
+				unformatted = true
+			}
+		} else if *writeFormat {
+			if formatted != source {
+				if err := os.WriteFile(pth, []byte(formatted), 0644); err != nil {
+					errors.LogCliError(err, 74)
+				}
+			}
+		} else {
+			fmt.Print(formatted)
+		}
+	}
+
+	if hadError {
+		os.Exit(65)
+	}
+
+	if unformatted {
+		os.Exit(1)
+	}
+}
+
+func LintFiles(paths []string) {
+	if len(paths) == 0 {
+		LogUsageMessage()
+	}
+
+	config := linting.Config{Disabled: map[string]bool{}}
+	if *enabledRules != "" {
+		enabled := ParseRules(*enabledRules)
+		for _, rule := range linting.Rules {
+			config.Disabled[rule] = !slices.Contains(enabled, rule)
+		}
+	}
+
+	for _, rule := range ParseRules(*disabledRules) {
+		config.Disabled[rule] = true
+	}
+
+	hadError := false
+	hadWarning := false
+	for _, pth := range paths {
+		warnings, errs := linting.Lint(LoadSource(pth), config)
+		for _, err := range errs {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", pth, err)
+		}
+
+		if len(errs) > 0 {
+			hadError = true
+			continue
+		}
+
+		for _, warning := range warnings {
+			fmt.Printf("%s:%d:%d: %s (%s)\n", pth, warning.Token.Line, warning.Token.Column, warning.Message, warning.Rule)
+			hadWarning = true
+		}
+	}
+
+	if hadError {
+		os.Exit(65)
+	}
+
+	if hadWarning {
+		os.Exit(1)
+	}
+}
+
+// serves the Language Server Protocol over stdin and stdout
+func ServeLsp(args []string) {
+	if len(args) > 0 {
+		LogUsageMessage()
+	}
+
+	if err := lsp.NewServer(os.Stdin, os.Stdout).Run(); err != nil {
+		errors.LogCliError(err, 1)
+	}
+}
+
+// runs a script under the debugger, controlled from the terminal or by a Debug Adapter Protocol client
+func DebugFile(args []string, scriptArgs []string) {
+	if len(args) != 1 {
+		LogUsageMessage()
+	}
+
+	source := LoadSource(args[0])
+	statements, parser, errs := BuildWithParser(source)
+	for _, err := range errs {
+		fmt.Fprintln(os.Stderr, err)
+	}
+
+	if len(errs) > 0 {
+		os.Exit(65)
+	}
+
+	interpreter := NewInterpreter()
+	interpreter.SetArgs(scriptArgs)
+	if hadError := resolving.NewResolver(interpreter).Resolve(statements); hadError {
+		os.Exit(65)
+	}
+
+	natives := interpreter.GlobalNames()
+	run := func(debugger *debugging.Debugger) error {
+		interpreter.SetDebugger(debugger)
+		return Interpret(interpreter, statements)
+	}
+
+	if *dapAddress != "" {
+		listener, err := net.Listen("tcp", *dapAddress)
+		if err != nil {
+			errors.LogCliError(err, 74)
+		}
+
+		fmt.Fprintln(os.Stderr, "golox: waiting for a debug adapter client on", listener.Addr())
+		conn, err := listener.Accept()
+		listener.Close()
+		if err != nil {
+			errors.LogCliError(err, 74)
+		}
+		defer conn.Close()
+
+		if err := debugging.ServeDap(conn, args[0], parser, natives, run); err != nil {
+			errors.LogCliError(err, 74)
+		}
+
+		return
+	}
+
+	// the debugger talks on stderr, leaving stdout to the program
+	console := debugging.NewConsole(os.Stdin, os.Stderr, source, natives)
+	debugger := debugging.NewDebugger(console, parser)
+	debugger.SetStopOnEntry(true)
+	if err := run(debugger); err != nil && err != debugging.ErrTerminated {
+		ExitIfRequested(err)
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(70)
+	}
+}
+
+// runs the tests in the given files and directories, exiting with 1 if any of them didn't pass
+func TestFiles(paths []string) {
+	if len(paths) == 0 {
+		LogUsageMessage()
+	}
+
+	format, ok := testrunner.ParseFormat(*testFormat)
+	if !ok {
+		errors.LogCliError(fmt.Sprintf("unknown test format '%s', expected text, tap or junit", *testFormat), 64)
+	}
+
+	files, err := testrunner.Discover(paths)
+	if err != nil {
+		errors.LogCliError(err, 66)
+	}
+
+	results := []testrunner.Result{}
+	for _, file := range files {
+		results = append(results, testrunner.RunFile(file, NewInterpreter, *timeout)...)
+	}
+
+	if err := testrunner.Write(os.Stdout, format, results); err != nil {
+		errors.LogCliError(err, 74)
+	}
+
+	if _, failed, errored := testrunner.Count(results); failed > 0 || errored > 0 {
+		os.Exit(1)
+	}
+}
+
+// runs the annotated .lox files in the given directories with this executable, and reports every difference
+// between their expected and actual behaviour. The directory defaults to testdata
+func CheckConformance(paths []string) {
+	if len(paths) == 0 {
+		paths = []string{"testdata"}
+	}
+
+	interpreter, err := os.Executable()
+	if err != nil {
+		errors.LogCliError(err, 70)
+	}
+
+	files, err := conformance.Discover(paths)
+	if err != nil {
+		errors.LogCliError(err, 66)
+	}
+
+	failed := 0
+	for _, file := range files {
+		result := conformance.RunFile(interpreter, file)
+		if result.Passed() {
+			continue
+		}
+
+		failed++
+		fmt.Printf("FAIL %s\n", file)
+		for _, failure := range result.Failures {
+			fmt.Printf("     %s\n", strings.ReplaceAll(failure, "\n", "\n     "))
+		}
+	}
+
+	fmt.Printf("%d passed, %d failed\n", len(files)-failed, failed)
+	if failed > 0 {
+		os.Exit(1)
+	}
+}
+
+// splits a comma separated list of lint rules, exiting if any of them is unknown
+func ParseRules(list string) []string {
+	rules := []string{}
+	for _, rule := range strings.Split(list, ",") {
+		rule = strings.TrimSpace(rule)
+		if rule == "" {
+			continue
+		}
+
+		if !slices.Contains(linting.Rules, rule) {
+			errors.LogCliError(fmt.Sprintf("unknown lint rule '%s', expected one of: %s", rule, strings.Join(linting.Rules, ", ")), 64)
+		}
+
+		rules = append(rules, rule)
+	}
+
+	return rules
 }
@@ -2,7 +2,6 @@ package parsing
 
 import (
 	"fmt"
-	"os"
 
 	"github.com/Drumstickz64/golox/assert"
 	"github.com/Drumstickz64/golox/ast"
@@ -11,37 +10,62 @@ import (
 )
 
 type Parser struct {
-	// used in case only non panicing errors occured, though it gets set to true even by panicing errors.
+	// set by both panicing and non panicing errors, all of which are returned by Parser.Parse().
 	// panicing errors are returned all the way up to the called to Parser.Parse().
 	// They also cause the parser to synchronize() at the declaration rule.
 	HadError bool
+	// the source span of every parsed statement, except for the ones created when desugaring for loops
+	Spans map[ast.Stmt]ast.Span
+	// for loops as they were written, keyed by the statement they were desugared into
+	ForLoops map[ast.Stmt]*ast.ForLoop
 
 	tokens  []token.Token
 	current int
+	errs    []error
 }
 
 func NewParser(tokens []token.Token) Parser {
 	return Parser{
-		tokens: tokens,
+		Spans:    map[ast.Stmt]ast.Span{},
+		ForLoops: map[ast.Stmt]*ast.ForLoop{},
+		tokens:   tokens,
 	}
 }
 
-func (p *Parser) Parse() ([]ast.Stmt, []error) {
-	statements := []ast.Stmt{}
-	errs := []error{}
+// Parse returns the statements that could be parsed, and the errors found in the others.
+// A failed internal assertion is returned as an errors.InternalError, along with the statements parsed before it
+func (p *Parser) Parse() (statements []ast.Stmt, errs []error) {
+	defer errors.RecoverAssertion(p.position, func(err *errors.InternalError) {
+		p.HadError = true
+		p.errs = append(p.errs, err)
+		errs = p.errs
+	})
+
+	statements = []ast.Stmt{}
 	for !p.isAtEnd() {
 		statement, err := p.declaration()
 		if err != nil {
-			errs = append(errs, err)
+			p.errs = append(p.errs, err)
 		} else {
 			statements = append(statements, statement)
 		}
 	}
 
-	return statements, errs
+	return statements, p.errs
 }
 
 func (p *Parser) declaration() (ast.Stmt, error) {
+	start := p.peek()
+	statement, err := p.unspannedDeclaration()
+	if err != nil {
+		return nil, err
+	}
+
+	p.Spans[statement] = ast.Span{Start: start, End: p.previous()}
+	return statement, nil
+}
+
+func (p *Parser) unspannedDeclaration() (ast.Stmt, error) {
 	if p.match(token.CLASS) {
 		class, err := p.classDeclaration()
 		if err != nil {
@@ -101,10 +125,12 @@ func (p *Parser) classDeclaration() (*ast.ClassStmt, error) {
 
 	methods := []*ast.FunctionStmt{}
 	for !p.check(token.RIGHT_BRACE) && !p.isAtEnd() {
+		start := p.peek()
 		method, err := p.function("method")
 		if err != nil {
 			return nil, err
 		}
+		p.Spans[method] = ast.Span{Start: start, End: p.previous()}
 		methods = append(methods, method)
 	}
 
@@ -133,7 +159,7 @@ func (p *Parser) function(kind string) (*ast.FunctionStmt, error) {
 	if !p.check(token.RIGHT_PAREN) {
 		for {
 			if len(parameters) >= 255 {
-				fmt.Fprintln(os.Stderr, p.error(p.peek(), "can't have more than 255 parameters"))
+				p.errs = append(p.errs, p.error(p.peek(), "can't have more than 255 parameters"))
 			}
 
 			parameter, err := p.consume(token.IDENTIFIER, "expected parameter name")
@@ -192,6 +218,17 @@ func (p *Parser) varDeclaration() (ast.Stmt, error) {
 }
 
 func (p *Parser) statement() (ast.Stmt, error) {
+	start := p.peek()
+	statement, err := p.unspannedStatement()
+	if err != nil {
+		return nil, err
+	}
+
+	p.Spans[statement] = ast.Span{Start: start, End: p.previous()}
+	return statement, nil
+}
+
+func (p *Parser) unspannedStatement() (ast.Stmt, error) {
 	if p.match(token.PRINT) {
 		return p.printStatement()
 	}
@@ -263,6 +300,7 @@ func (p *Parser) returnStatement() (ast.Stmt, error) {
 }
 
 func (p *Parser) forStatement() (ast.Stmt, error) {
+	keyword := p.previous()
 	var err error
 	if _, err = p.consume(token.LEFT_PAREN, "expected '(' after 'for'"); err != nil {
 		return nil, err
@@ -312,24 +350,29 @@ func (p *Parser) forStatement() (ast.Stmt, error) {
 		return nil, err
 	}
 
+	loop := &ast.ForLoop{
+		Keyword:     keyword,
+		Initializer: initializer,
+		Condition:   condition,
+		Increment:   increment,
+		Body:        body,
+	}
+
 	if increment != nil {
 		body = &ast.BlockStmt{
 			Statements: []ast.Stmt{body, &ast.ExpressionStmt{Expression: increment}},
 		}
 	}
 
-	if condition != nil {
-		body = &ast.WhileStmt{
-			Condition: condition,
-			Body:      body,
-		}
-	} else {
-		body = &ast.WhileStmt{
-			Condition: &ast.LiteralExpr{Value: true},
-			Body:      body,
-		}
+	if condition == nil {
+		condition = &ast.LiteralExpr{Value: true}
+	}
 
+	loop.While = &ast.WhileStmt{
+		Condition: condition,
+		Body:      body,
 	}
+	body = loop.While
 
 	if initializer != nil {
 		body = &ast.BlockStmt{
@@ -337,6 +380,8 @@ func (p *Parser) forStatement() (ast.Stmt, error) {
 		}
 	}
 
+	p.ForLoops[body] = loop
+
 	return body, nil
 }
 
@@ -464,7 +509,7 @@ func (p *Parser) assignment() (ast.Expr, error) {
 			}, nil
 
 		default:
-			fmt.Fprintln(os.Stderr, p.error(equals, "invalid assignment target"))
+			p.errs = append(p.errs, p.error(equals, "invalid assignment target"))
 		}
 
 	}
@@ -782,6 +827,12 @@ func (p *Parser) peek() token.Token {
 	return p.tokens[p.current]
 }
 
+// where the parser is, for internal errors
+func (p *Parser) position() (int, int) {
+	tok := p.peek()
+	return tok.Line, tok.Column
+}
+
 func (p *Parser) previous() token.Token {
 	assert.That(p.current > 0, "parser is not at the start of token list")
 	return p.tokens[p.current-1]
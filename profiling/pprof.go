@@ -0,0 +1,201 @@
+package profiling
+
+import (
+	"cmp"
+	"compress/gzip"
+	"io"
+	"slices"
+
+	"github.com/Drumstickz64/golox/ast"
+)
+
+// field numbers of the messages in pprof's profile.proto
+const (
+	PROFILE_SAMPLE_TYPE    = 1
+	PROFILE_SAMPLE         = 2
+	PROFILE_LOCATION       = 4
+	PROFILE_FUNCTION       = 5
+	PROFILE_STRING_TABLE   = 6
+	PROFILE_TIME_NANOS     = 9
+	PROFILE_DURATION_NANOS = 10
+	PROFILE_PERIOD_TYPE    = 11
+	PROFILE_PERIOD         = 12
+
+	VALUE_TYPE_TYPE = 1
+	VALUE_TYPE_UNIT = 2
+
+	SAMPLE_LOCATION_ID = 1
+	SAMPLE_VALUE       = 2
+
+	LOCATION_ID   = 1
+	LOCATION_LINE = 4
+
+	LINE_FUNCTION_ID = 1
+	LINE_LINE        = 2
+
+	FUNCTION_ID          = 1
+	FUNCTION_NAME        = 2
+	FUNCTION_SYSTEM_NAME = 3
+	FUNCTION_FILENAME    = 4
+	FUNCTION_START_LINE  = 5
+)
+
+const (
+	WIRE_TYPE_VARINT = 0
+	WIRE_TYPE_BYTES  = 2
+)
+
+// WritePprof writes the profile in the gzipped protocol buffer format read by 'go tool pprof'.
+// Every sample has the number of calls made and the time spent with its stack
+func (p *Profiler) WritePprof(w io.Writer) error {
+	profile := &pprofBuilder{strings: map[string]int{}}
+	profile.stringIndex("")
+
+	functionIds := map[*ast.FunctionStmt]uint64{}
+	// functions are added in a stable order, so the same profile is always written the same way
+	declarations := []*ast.FunctionStmt{}
+	for declaration := range p.functions {
+		declarations = append(declarations, declaration)
+	}
+	slices.SortFunc(declarations, func(a, b *ast.FunctionStmt) int {
+		return cmp.Compare(p.functions[a].Line, p.functions[b].Line)
+	})
+
+	functions := []byte{}
+	for _, declaration := range declarations {
+		stats := p.functions[declaration]
+		id := uint64(len(functionIds) + 1)
+		functionIds[declaration] = id
+
+		function := []byte{}
+		function = appendVarintField(function, FUNCTION_ID, id)
+		function = appendVarintField(function, FUNCTION_NAME, uint64(profile.stringIndex(stats.Name)))
+		function = appendVarintField(function, FUNCTION_SYSTEM_NAME, uint64(profile.stringIndex(stats.Name)))
+		function = appendVarintField(function, FUNCTION_FILENAME, uint64(profile.stringIndex(p.filename)))
+		function = appendVarintField(function, FUNCTION_START_LINE, uint64(stats.Line))
+		functions = appendBytesField(functions, PROFILE_FUNCTION, function)
+	}
+
+	locationIds := map[location]uint64{}
+	locations := []byte{}
+	samples := []byte{}
+	for _, s := range p.sortedSamples() {
+		ids := []uint64{}
+		for _, loc := range s.stack {
+			id, ok := locationIds[loc]
+			if !ok {
+				id = uint64(len(locationIds) + 1)
+				locationIds[loc] = id
+
+				line := []byte{}
+				line = appendVarintField(line, LINE_FUNCTION_ID, functionIds[loc.declaration])
+				line = appendVarintField(line, LINE_LINE, uint64(loc.line))
+
+				location := []byte{}
+				location = appendVarintField(location, LOCATION_ID, id)
+				location = appendBytesField(location, LOCATION_LINE, line)
+				locations = appendBytesField(locations, PROFILE_LOCATION, location)
+			}
+
+			ids = append(ids, id)
+		}
+
+		sample := []byte{}
+		sample = appendPackedField(sample, SAMPLE_LOCATION_ID, ids)
+		sample = appendPackedField(sample, SAMPLE_VALUE, []uint64{uint64(s.calls), uint64(s.time.Nanoseconds())})
+		samples = appendBytesField(samples, PROFILE_SAMPLE, sample)
+	}
+
+	encoded := []byte{}
+	encoded = appendBytesField(encoded, PROFILE_SAMPLE_TYPE, profile.valueType("calls", "count"))
+	encoded = appendBytesField(encoded, PROFILE_SAMPLE_TYPE, profile.valueType("time", "nanoseconds"))
+	encoded = append(encoded, samples...)
+	encoded = append(encoded, locations...)
+	encoded = append(encoded, functions...)
+	// the period type refers to strings, so the string table is written after everything else
+	periodType := profile.valueType("time", "nanoseconds")
+	for _, str := range profile.table {
+		encoded = appendBytesField(encoded, PROFILE_STRING_TABLE, []byte(str))
+	}
+	encoded = appendVarintField(encoded, PROFILE_TIME_NANOS, uint64(p.start.UnixNano()))
+	encoded = appendVarintField(encoded, PROFILE_DURATION_NANOS, uint64(p.duration.Nanoseconds()))
+	encoded = appendBytesField(encoded, PROFILE_PERIOD_TYPE, periodType)
+	encoded = appendVarintField(encoded, PROFILE_PERIOD, 1)
+
+	gzipped := gzip.NewWriter(w)
+	if _, err := gzipped.Write(encoded); err != nil {
+		return err
+	}
+
+	return gzipped.Close()
+}
+
+func (p *Profiler) sortedSamples() []*sample {
+	samples := make([]*sample, 0, len(p.samples))
+	for _, s := range p.samples {
+		samples = append(samples, s)
+	}
+
+	slices.SortFunc(samples, func(a, b *sample) int {
+		return cmp.Compare(b.time, a.time)
+	})
+
+	return samples
+}
+
+type pprofBuilder struct {
+	table   []string
+	strings map[string]int
+}
+
+// returns the index of str in the string table, adding it if needed
+func (b *pprofBuilder) stringIndex(str string) int {
+	index, ok := b.strings[str]
+	if !ok {
+		index = len(b.table)
+		b.strings[str] = index
+		b.table = append(b.table, str)
+	}
+
+	return index
+}
+
+func (b *pprofBuilder) valueType(typ, unit string) []byte {
+	encoded := []byte{}
+	encoded = appendVarintField(encoded, VALUE_TYPE_TYPE, uint64(b.stringIndex(typ)))
+	encoded = appendVarintField(encoded, VALUE_TYPE_UNIT, uint64(b.stringIndex(unit)))
+	return encoded
+}
+
+func appendVarint(encoded []byte, value uint64) []byte {
+	for value >= 0x80 {
+		encoded = append(encoded, byte(value)|0x80)
+		value >>= 7
+	}
+
+	return append(encoded, byte(value))
+}
+
+func appendTag(encoded []byte, field int, wireType int) []byte {
+	return appendVarint(encoded, uint64(field)<<3|uint64(wireType))
+}
+
+func appendVarintField(encoded []byte, field int, value uint64) []byte {
+	encoded = appendTag(encoded, field, WIRE_TYPE_VARINT)
+	return appendVarint(encoded, value)
+}
+
+func appendBytesField(encoded []byte, field int, value []byte) []byte {
+	encoded = appendTag(encoded, field, WIRE_TYPE_BYTES)
+	encoded = appendVarint(encoded, uint64(len(value)))
+	return append(encoded, value...)
+}
+
+func appendPackedField(encoded []byte, field int, values []uint64) []byte {
+	packed := []byte{}
+	for _, value := range values {
+		packed = appendVarint(packed, value)
+	}
+
+	return appendBytesField(encoded, field, packed)
+}
@@ -0,0 +1,254 @@
+package profiling
+
+import (
+	"cmp"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Drumstickz64/golox/ast"
+	"github.com/Drumstickz64/golox/interpreting"
+	"github.com/Drumstickz64/golox/parsing"
+	"github.com/Drumstickz64/golox/token"
+)
+
+// Profiler is an interpreter observer that measures the time spent in every Lox function and on every source line.
+// Time is measured between consecutive statements and calls, so it includes the time spent in natives
+type Profiler struct {
+	filename string
+	spans    map[ast.Stmt]ast.Span
+	// the calls being executed, the script itself is at the bottom
+	stack []*frame
+	// the time of the last statement or call, the time since then is spent on the current line
+	last      time.Time
+	start     time.Time
+	duration  time.Duration
+	functions map[*ast.FunctionStmt]*FunctionStats
+	lines     map[int]*LineStats
+	// time and calls keyed by the stack they happened in, which is what pprof stores
+	samples map[string]*sample
+}
+
+type frame struct {
+	// nil for the script
+	declaration *ast.FunctionStmt
+	start       time.Time
+	// the line being executed in this frame
+	line int
+}
+
+type FunctionStats struct {
+	// "script" for top-level code
+	Name string
+	Line int
+	// number of times the function was called
+	Calls int
+	// time spent in the function itself
+	Self time.Duration
+	// time spent in the function and everything it called, recursive calls are only counted once
+	Cumulative time.Duration
+	// how many calls to this function are being executed, used to avoid counting recursive calls twice
+	active int
+}
+
+type LineStats struct {
+	Line int
+	// number of statements executed on the line
+	Hits int
+	Self time.Duration
+}
+
+type sample struct {
+	// innermost first
+	stack []location
+	calls int64
+	time  time.Duration
+}
+
+type location struct {
+	declaration *ast.FunctionStmt
+	line        int
+}
+
+// filename is the path of the profiled script, and parser the one that parsed it
+func NewProfiler(filename string, parser parsing.Parser) *Profiler {
+	now := time.Now()
+	script := &FunctionStats{Name: "script", Calls: 1, active: 1}
+	return &Profiler{
+		filename:  filename,
+		spans:     parser.Spans,
+		stack:     []*frame{{start: now}},
+		last:      now,
+		start:     now,
+		functions: map[*ast.FunctionStmt]*FunctionStats{nil: script},
+		lines:     map[int]*LineStats{},
+		samples:   map[string]*sample{},
+	}
+}
+
+func (p *Profiler) OnStatement(stmt ast.Stmt) {
+	p.spend()
+
+	span, ok := p.spans[stmt]
+	if !ok {
+		return
+	}
+
+	line := span.Start.Line
+	p.stack[len(p.stack)-1].line = line
+	p.lineStats(line).Hits++
+}
+
+func (p *Profiler) OnCall(callee interpreting.Callable, paren token.Token, arguments []any) {
+	declaration, ok := interpreting.FunctionDeclaration(callee)
+	if !ok {
+		return
+	}
+
+	p.spend()
+
+	stats := p.functionStats(declaration)
+	stats.Calls++
+	stats.active++
+	p.stack = append(p.stack, &frame{declaration: declaration, start: p.last, line: declaration.Name.Line})
+	p.sampleAt().calls++
+}
+
+func (p *Profiler) OnReturn(callee interpreting.Callable, paren token.Token, result any, err error) {
+	declaration, ok := interpreting.FunctionDeclaration(callee)
+	if !ok {
+		return
+	}
+
+	p.spend()
+
+	returned := p.stack[len(p.stack)-1]
+	p.stack = p.stack[:len(p.stack)-1]
+
+	stats := p.functionStats(declaration)
+	stats.active--
+	if stats.active == 0 {
+		stats.Cumulative += p.last.Sub(returned.start)
+	}
+}
+
+func (p *Profiler) OnAssignment(name token.Token, value any) {}
+
+// Finish stops measuring, it's called once the program ends
+func (p *Profiler) Finish() {
+	p.spend()
+	p.duration = p.last.Sub(p.start)
+	p.functions[nil].Cumulative = p.duration
+}
+
+// attributes the time since the last event to the line being executed
+func (p *Profiler) spend() {
+	now := time.Now()
+	elapsed := now.Sub(p.last)
+	p.last = now
+
+	top := p.stack[len(p.stack)-1]
+	p.functionStats(top.declaration).Self += elapsed
+	if top.line > 0 {
+		p.lineStats(top.line).Self += elapsed
+	}
+	p.sampleAt().time += elapsed
+}
+
+// returns the sample for the current stack
+func (p *Profiler) sampleAt() *sample {
+	stack := make([]location, 0, len(p.stack))
+	key := strings.Builder{}
+	for i := len(p.stack) - 1; i >= 0; i-- {
+		frame := p.stack[i]
+		stack = append(stack, location{declaration: frame.declaration, line: frame.line})
+		fmt.Fprintf(&key, "%p:%d;", frame.declaration, frame.line)
+	}
+
+	s, ok := p.samples[key.String()]
+	if !ok {
+		s = &sample{stack: stack}
+		p.samples[key.String()] = s
+	}
+
+	return s
+}
+
+func (p *Profiler) functionStats(declaration *ast.FunctionStmt) *FunctionStats {
+	stats, ok := p.functions[declaration]
+	if !ok {
+		stats = &FunctionStats{Name: declaration.Name.Lexeme, Line: declaration.Name.Line}
+		p.functions[declaration] = stats
+	}
+
+	return stats
+}
+
+func (p *Profiler) lineStats(line int) *LineStats {
+	stats, ok := p.lines[line]
+	if !ok {
+		stats = &LineStats{Line: line}
+		p.lines[line] = stats
+	}
+
+	return stats
+}
+
+// Functions returns the stats of every function that was called, sorted by self time
+func (p *Profiler) Functions() []FunctionStats {
+	functions := []FunctionStats{}
+	for _, stats := range p.functions {
+		functions = append(functions, *stats)
+	}
+
+	slices.SortFunc(functions, func(a, b FunctionStats) int {
+		return cmp.Or(cmp.Compare(b.Self, a.Self), cmp.Compare(a.Line, b.Line))
+	})
+
+	return functions
+}
+
+// Lines returns the stats of every line that was executed, sorted by self time
+func (p *Profiler) Lines() []LineStats {
+	lines := []LineStats{}
+	for _, stats := range p.lines {
+		lines = append(lines, *stats)
+	}
+
+	slices.SortFunc(lines, func(a, b LineStats) int {
+		return cmp.Or(cmp.Compare(b.Self, a.Self), cmp.Compare(a.Line, b.Line))
+	})
+
+	return lines
+}
+
+// WriteSummary writes the top functions and lines by self time as a table
+func (p *Profiler) WriteSummary(w io.Writer, top int) error {
+	summary := strings.Builder{}
+	fmt.Fprintf(&summary, "total time %v\n\n", p.duration)
+
+	fmt.Fprintf(&summary, "%8s %12s %7s %12s %7s  %s\n", "calls", "self", "self%", "cum", "cum%", "function")
+	for _, stats := range p.Functions()[:min(max(top, 0), len(p.functions))] {
+		fmt.Fprintf(&summary, "%8d %12v %6.2f%% %12v %6.2f%%  %s (%s:%d)\n",
+			stats.Calls, stats.Self, p.percentage(stats.Self), stats.Cumulative, p.percentage(stats.Cumulative),
+			stats.Name, p.filename, stats.Line)
+	}
+
+	fmt.Fprintf(&summary, "\n%8s %12s %7s  %s\n", "hits", "self", "self%", "line")
+	for _, stats := range p.Lines()[:min(max(top, 0), len(p.lines))] {
+		fmt.Fprintf(&summary, "%8d %12v %6.2f%%  %s:%d\n", stats.Hits, stats.Self, p.percentage(stats.Self), p.filename, stats.Line)
+	}
+
+	_, err := io.WriteString(w, summary.String())
+	return err
+}
+
+func (p *Profiler) percentage(duration time.Duration) float64 {
+	if p.duration == 0 {
+		return 0
+	}
+
+	return float64(duration) / float64(p.duration) * 100
+}
@@ -0,0 +1,177 @@
+package repl
+
+import (
+	goerrors "errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Drumstickz64/golox/ast"
+	"github.com/Drumstickz64/golox/interpreting"
+)
+
+// a meta-command, entered as a colon followed by its name
+type command struct {
+	name string
+	// describes the argument, empty if there is none
+	argument    string
+	description string
+	// returns true to quit the REPL
+	run func(r *Repl, argument string) (bool, error)
+}
+
+func (r *Repl) commands() []command {
+	return []command{
+		{"load", "file.lox", "run a file in the session", (*Repl).load},
+		{"reset", "", "start over with fresh globals", (*Repl).resetCommand},
+		{"env", "", "list the globals defined in the session", (*Repl).env},
+		{"type", "expr", "show the type of an expression's value", (*Repl).typeOf},
+		{"ast", "code", "show the syntax tree of code without running it", (*Repl).printAst},
+		{"time", "code", "run code and show how long it took", (*Repl).time},
+		{"save", "file.lox", "write the inputs of the session to a file", (*Repl).save},
+		{"help", "", "list the commands", (*Repl).help},
+		{"quit", "", "leave the REPL", (*Repl).quit},
+	}
+}
+
+// runs the meta-command on line, reporting its errors. Returns true to quit the REPL,
+// and the error of a script calling the 'exit' native
+func (r *Repl) command(line string) (bool, error) {
+	name, argument, _ := strings.Cut(strings.TrimPrefix(line, ":"), " ")
+	argument = strings.TrimSpace(argument)
+
+	index := slices.IndexFunc(r.commands(), func(c command) bool { return c.name == name })
+	if index < 0 {
+		fmt.Fprintf(r.errOut, "unknown command ':%s', enter :help to list the commands\n", name)
+		return false, nil
+	}
+
+	cmd := r.commands()[index]
+	if cmd.argument != "" && argument == "" {
+		fmt.Fprintf(r.errOut, "usage: :%s %s\n", cmd.name, cmd.argument)
+		return false, nil
+	}
+
+	quit, err := cmd.run(r, argument)
+	var exitErr *interpreting.ExitError
+	if goerrors.As(err, &exitErr) {
+		return true, err
+	}
+
+	if err != nil {
+		fmt.Fprintln(r.errOut, err)
+	}
+
+	return quit, nil
+}
+
+func (r *Repl) load(pth string) (bool, error) {
+	source, err := os.ReadFile(pth)
+	if err != nil {
+		return false, err
+	}
+
+	statements, completed, errs, _ := build(string(source))
+	_, err = r.execute(completed, statements, errs)
+	return false, err
+}
+
+func (r *Repl) resetCommand(argument string) (bool, error) {
+	r.reset()
+	return false, nil
+}
+
+func (r *Repl) env(argument string) (bool, error) {
+	globals := r.interpreter.Globals()
+	for _, name := range r.interpreter.GlobalNames() {
+		if slices.Contains(r.natives, name) {
+			continue
+		}
+
+		value, _ := globals.Value(name)
+		fmt.Fprintf(r.out, "%s = %s\n", name, interpreting.Stringify(value))
+	}
+
+	return false, nil
+}
+
+func (r *Repl) typeOf(source string) (bool, error) {
+	statements, _, errs, _ := build(source)
+	if len(errs) > 0 {
+		return false, goerrors.Join(errs...)
+	}
+
+	if len(statements) != 1 {
+		return false, goerrors.New(":type expects a single expression")
+	}
+
+	expression, ok := statements[0].(*ast.ExpressionStmt)
+	if !ok {
+		return false, goerrors.New(":type expects a single expression")
+	}
+
+	if hadError := r.resolver.Resolve(statements); hadError {
+		return false, nil
+	}
+
+	value, err := r.interpreter.Evaluate(expression.Expression)
+	if err != nil {
+		return false, err
+	}
+
+	fmt.Fprintln(r.out, interpreting.TypeOf(value))
+	return false, nil
+}
+
+func (r *Repl) printAst(source string) (bool, error) {
+	statements, _, errs, _ := build(source)
+	if len(errs) > 0 {
+		return false, goerrors.Join(errs...)
+	}
+
+	tree, err := ast.NewPrinter().PrintProgram(statements)
+	if err != nil {
+		return false, err
+	}
+
+	fmt.Fprintln(r.out, tree)
+	return false, nil
+}
+
+func (r *Repl) time(source string) (bool, error) {
+	statements, completed, errs, _ := build(source)
+	start := time.Now()
+	succeeded, err := r.execute(completed, statements, errs)
+	if err != nil {
+		return false, err
+	}
+
+	if succeeded {
+		fmt.Fprintf(r.out, "took %v\n", time.Since(start))
+	}
+
+	return false, nil
+}
+
+func (r *Repl) save(pth string) (bool, error) {
+	return false, os.WriteFile(pth, []byte(strings.Join(r.inputs, "")), 0644)
+}
+
+func (r *Repl) help(argument string) (bool, error) {
+	for _, cmd := range r.commands() {
+		usage := ":" + cmd.name
+		if cmd.argument != "" {
+			usage += " " + cmd.argument
+		}
+
+		fmt.Fprintf(r.out, "%-18s %s\n", usage, cmd.description)
+	}
+
+	return false, nil
+}
+
+func (r *Repl) quit(argument string) (bool, error) {
+	return true, nil
+}
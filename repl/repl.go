@@ -0,0 +1,271 @@
+package repl
+
+import (
+	"context"
+	goerrors "errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"slices"
+	"strings"
+
+	"github.com/Drumstickz64/golox/ast"
+	"github.com/Drumstickz64/golox/editing"
+	"github.com/Drumstickz64/golox/errors"
+	"github.com/Drumstickz64/golox/interpreting"
+	"github.com/Drumstickz64/golox/parsing"
+	"github.com/Drumstickz64/golox/resolving"
+	"github.com/Drumstickz64/golox/scanning"
+	"github.com/Drumstickz64/golox/token"
+)
+
+const (
+	PROMPT = "> "
+	// shown while reading the rest of an incomplete input
+	CONTINUATION_PROMPT = "... "
+)
+
+// Repl reads code from the user and runs it, keeping the state of the program between inputs
+type Repl struct {
+	editor         *editing.Editor
+	out            io.Writer
+	errOut         io.Writer
+	newInterpreter func() *interpreting.Interpreter
+	interpreter    *interpreting.Interpreter
+	resolver       *resolving.Resolver
+	// globals defined by the interpreter before any input, which aren't listed by :env
+	natives []string
+	// inputs that were run, in order, written by :save
+	inputs []string
+}
+
+// newInterpreter creates the interpreter inputs are run in, and is called again by :reset.
+// Values of bare expressions and printed values are written to out, and errors to errOut
+func New(editor *editing.Editor, newInterpreter func() *interpreting.Interpreter, out, errOut io.Writer) *Repl {
+	r := &Repl{
+		editor:         editor,
+		out:            out,
+		errOut:         errOut,
+		newInterpreter: newInterpreter,
+	}
+
+	r.reset()
+	editor.Complete = r.complete
+	return r
+}
+
+// starts over with a fresh interpreter, forgetting every input
+func (r *Repl) reset() {
+	r.interpreter = r.newInterpreter()
+	r.interpreter.SetRedefineInPlace(true)
+	r.interpreter.SetOutput(r.out)
+	r.interpreter.SetSharedInput(r.editor.Input())
+	r.resolver = resolving.NewResolver(r.interpreter)
+	r.resolver.SetErrorOutput(r.errOut)
+	r.natives = r.interpreter.GlobalNames()
+	r.inputs = nil
+}
+
+// Run reads and runs inputs until the end of the input.
+// An input spans lines until it's complete, or until an empty line is entered.
+// Returns the error of a script calling the 'exit' native
+func (r *Repl) Run() error {
+	source := ""
+	for {
+		prompt := PROMPT
+		if source != "" {
+			prompt = CONTINUATION_PROMPT
+		}
+
+		line, err := r.editor.ReadLine(prompt)
+		if goerrors.Is(err, editing.ErrInterrupted) {
+			source = ""
+			continue
+		}
+
+		atEnd := goerrors.Is(err, io.EOF)
+		if err != nil && !atEnd {
+			return err
+		}
+
+		if err := r.editor.AddHistory(line); err != nil {
+			fmt.Fprintln(r.errOut, "golox: failed to save history:", err)
+		}
+
+		if source == "" && strings.HasPrefix(strings.TrimSpace(line), ":") {
+			quit, err := r.command(strings.TrimSpace(line))
+			if err != nil || quit {
+				return err
+			}
+
+			if atEnd {
+				return nil
+			}
+
+			continue
+		}
+
+		if source == "" && strings.TrimSpace(line) == "" {
+			if atEnd {
+				return nil
+			}
+
+			continue
+		}
+
+		source += line + "\n"
+		// an empty line runs the input even if it's incomplete, showing why it is
+		statements, completed, errs, incomplete := build(source)
+		if incomplete && strings.TrimSpace(line) != "" && !atEnd {
+			continue
+		}
+
+		source = ""
+		if _, err := r.execute(completed, statements, errs); err != nil {
+			return err
+		}
+
+		if atEnd {
+			return nil
+		}
+	}
+}
+
+// runs a complete input, reporting its errors. Returns whether it ran without errors,
+// and the error of a script calling the 'exit' native
+func (r *Repl) execute(source string, statements []ast.Stmt, errs []error) (bool, error) {
+	for _, err := range errs {
+		fmt.Fprintln(r.errOut, err)
+	}
+
+	if len(errs) > 0 {
+		return false, nil
+	}
+
+	if hadError := r.resolver.Resolve(statements); hadError {
+		return false, nil
+	}
+
+	r.inputs = append(r.inputs, source)
+	if err := r.run(statements); err != nil {
+		var exitErr *interpreting.ExitError
+		if goerrors.As(err, &exitErr) {
+			return false, err
+		}
+
+		fmt.Fprintln(r.errOut, err)
+		return false, nil
+	}
+
+	return true, nil
+}
+
+// runs resolved statements, writing the values of bare expressions. Pressing Ctrl-C cancels them
+func (r *Repl) run(statements []ast.Stmt) error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	for _, statement := range statements {
+		expression, ok := statement.(*ast.ExpressionStmt)
+		if !ok {
+			if err := r.interpreter.InterpretContext(ctx, []ast.Stmt{statement}); err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		value, err := r.interpreter.EvaluateContext(ctx, expression.Expression)
+		if err != nil {
+			return err
+		}
+
+		if shouldEcho(expression.Expression, value) {
+			fmt.Fprintln(r.out, interpreting.Stringify(value))
+		}
+	}
+
+	return nil
+}
+
+// assignments already show their value, and calls returning nothing are made for their side effects
+func shouldEcho(expr ast.Expr, value any) bool {
+	switch expr.(type) {
+	case *ast.AssignmentExpr, *ast.SetExpr:
+		return false
+	case *ast.CallExpr:
+		return value != nil
+	}
+
+	return true
+}
+
+// builds source, reporting whether it's incomplete, meaning that all errors are caused by the source ending early.
+// Also returns the source that was built, which has a semicolon added after a final bare expression
+func build(source string) ([]ast.Stmt, string, []error, bool) {
+	statements, errs, eof := parse(source)
+	if len(errs) == 0 {
+		return statements, source, nil, false
+	}
+
+	for _, err := range errs {
+		if !isAtEnd(err, eof) {
+			return nil, source, errs, false
+		}
+	}
+
+	// a bare expression doesn't need its semicolon
+	completed := strings.TrimRight(source, "\n") + ";\n"
+	if statements, moreErrs, _ := parse(completed); len(moreErrs) == 0 && len(statements) > 0 {
+		if _, ok := statements[len(statements)-1].(*ast.ExpressionStmt); ok {
+			return statements, completed, nil, false
+		}
+	}
+
+	return nil, source, errs, true
+}
+
+// scans and parses source, also returning the EOF token
+func parse(source string) ([]ast.Stmt, []error, token.Token) {
+	scanner := scanning.NewScanner(source)
+	tokens, errs := scanner.ScanTokens()
+	eof := tokens[len(tokens)-1]
+	if len(errs) > 0 {
+		return nil, errs, eof
+	}
+
+	parser := parsing.NewParser(tokens)
+	statements, errs := parser.Parse()
+	return statements, errs, eof
+}
+
+// whether err was reported at the end of the source, like a missing brace or an unterminated string
+func isAtEnd(err error, eof token.Token) bool {
+	var buildtimeErr *errors.BuildtimeError
+	if !goerrors.As(err, &buildtimeErr) {
+		return false
+	}
+
+	// the scanner reports errors at the position it stopped at, which is just before EOF
+	return buildtimeErr.Where == " at end" || buildtimeErr.Line == eof.Line && buildtimeErr.Column == eof.Column-1
+}
+
+// completes word with the names of globals and keywords
+func (r *Repl) complete(word string) []string {
+	candidates := []string{}
+	for _, name := range r.interpreter.GlobalNames() {
+		if strings.HasPrefix(name, word) {
+			candidates = append(candidates, name)
+		}
+	}
+
+	for keyword := range token.Keywords {
+		if strings.HasPrefix(keyword, word) {
+			candidates = append(candidates, keyword)
+		}
+	}
+
+	slices.Sort(candidates)
+	return slices.Compact(candidates)
+}
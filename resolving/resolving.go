@@ -2,7 +2,10 @@ package resolving
 
 import (
 	"fmt"
+	"io"
 	"os"
+	"slices"
+	"strings"
 
 	"github.com/Drumstickz64/golox/ast"
 	"github.com/Drumstickz64/golox/errors"
@@ -27,26 +30,186 @@ const (
 	CLASS_TYPE_SUBCLASS
 )
 
+type variableKind int
+
+const (
+	VARIABLE_KIND_VARIABLE variableKind = iota
+	VARIABLE_KIND_PARAMETER
+	VARIABLE_KIND_FUNCTION
+	VARIABLE_KIND_CLASS
+	// 'this' and 'super', which are declared by the resolver itself
+	VARIABLE_KIND_IMPLICIT
+)
+
+// rules for the warnings found while resolving, which are only reported by the linter
+const (
+	RULE_UNUSED_VARIABLE             = "unused-variable"
+	RULE_UNUSED_PARAMETER            = "unused-parameter"
+	RULE_UNUSED_FUNCTION             = "unused-function"
+	RULE_SHADOWED_NAME               = "shadowed-name"
+	RULE_UNDEFINED_GLOBAL_ASSIGNMENT = "undefined-global-assignment"
+	RULE_WRONG_ARITY                 = "wrong-arity"
+)
+
+type Warning struct {
+	Rule    string
+	Token   token.Token
+	Message string
+}
+
+func (w Warning) String() string {
+	return fmt.Sprintf("[line %d:%d] Warning at '%s': %s (%s)", w.Token.Line, w.Token.Column, w.Token.Lexeme, w.Message, w.Rule)
+}
+
+type Reference struct {
+	// the token where a variable is used
+	Use token.Token
+	// the token where the variable is declared
+	Declaration token.Token
+}
+
+type variable struct {
+	name      token.Token
+	kind      variableKind
+	isDefined bool
+	isUsed    bool
+	// the function or class declared by the variable, used to check the arity of calls
+	declaration ast.Stmt
+}
+
 type Resolver struct {
 	interpreter  *interpreting.Interpreter
-	scopes       []map[string]bool
+	scopes       []map[string]*variable
 	currFunction functionType
 	currClass    classType
 	hadError     bool
+	errs         []error
+	// where errors are written as they're reported
+	errOut io.Writer
+	// the innermost statement being resolved, which tells where internal errors happened
+	statement ast.Stmt
+
+	// used by tooling to find where variables are declared
+	references       []Reference
+	globalReferences []token.Token
+
+	// used only for warnings, which need to know about globals as well
+	warnings []Warning
+	// globals declared by every call to Resolve, which later calls can use
+	globals map[string]*variable
+	// globals declared by the last call to Resolve
+	declaredGlobals   []*variable
+	readGlobals       map[string]bool
+	globalAssignments []token.Token
+	globalCalls       []*ast.CallExpr
 }
 
 func NewResolver(interpreter *interpreting.Interpreter) *Resolver {
 	return &Resolver{
 		interpreter: interpreter,
-		scopes:      []map[string]bool{},
+		errOut:      os.Stderr,
+		scopes:      []map[string]*variable{},
+		globals:     map[string]*variable{},
+		readGlobals: map[string]bool{},
 	}
 }
 
-func (r *Resolver) Resolve(statements []ast.Stmt) bool {
+// SetErrorOutput sets where errors are written as they're reported, it's the process' standard error by default.
+// Errors are also kept, and can be read with Errors, so tools can pass io.Discard to only get them from there
+func (r *Resolver) SetErrorOutput(errOut io.Writer) {
+	r.errOut = errOut
+}
+
+// Resolve reports whether statements had errors. The same resolver can resolve several programs
+// in turn, like the inputs of the REPL, which see the globals declared by the previous ones.
+// Errors, references and warnings are only kept for the last call.
+// A failed internal assertion is reported as an errors.InternalError, and the resolver can be used again afterwards
+func (r *Resolver) Resolve(statements []ast.Stmt) (hadError bool) {
+	defer errors.RecoverAssertion(r.position, func(err *errors.InternalError) {
+		// the resolver was left inside the scopes it failed in
+		r.scopes = []map[string]*variable{}
+		r.currFunction = FUNCTION_TYPE_NONE
+		r.currClass = CLASS_TYPE_NONE
+		r.statement = nil
+		r.report(err)
+		hadError = true
+	})
+
+	r.hadError = false
+	r.errs = nil
+	r.references = nil
+	r.globalReferences = nil
+	r.warnings = nil
+	r.declaredGlobals = nil
+	r.globalAssignments = nil
+	r.globalCalls = nil
 	r.resolveBlock(statements)
 	return r.hadError
 }
 
+// Errors returns the errors reported by the last call to Resolve
+func (r *Resolver) Errors() []error {
+	return r.errs
+}
+
+// References returns every use of a variable found by the last call to Resolve, along with its declaration,
+// which may have been resolved by an earlier call. Uses of natives and undeclared globals are left out
+func (r *Resolver) References() []Reference {
+	references := slices.Clone(r.references)
+	for _, use := range r.globalReferences {
+		if global, ok := r.globals[use.Lexeme]; ok {
+			references = append(references, Reference{Use: use, Declaration: global.name})
+		}
+	}
+
+	return references
+}
+
+// Warnings returns the likely mistakes found by the last call to Resolve, sorted by position.
+// Checks involving globals are done here, since globals may be used before they're declared
+func (r *Resolver) Warnings() []Warning {
+	warnings := slices.Clone(r.warnings)
+
+	for _, global := range r.declaredGlobals {
+		// a global redeclared later is reported with its last declaration
+		if r.globals[global.name.Lexeme] != global {
+			continue
+		}
+
+		if global.kind == VARIABLE_KIND_FUNCTION && !r.readGlobals[global.name.Lexeme] {
+			warnings = append(warnings, unusedWarning(global))
+		}
+	}
+
+	for _, name := range r.globalAssignments {
+		if _, declared := r.globals[name.Lexeme]; !declared && !r.interpreter.IsGlobal(name.Lexeme) {
+			warnings = append(warnings, Warning{
+				Rule:    RULE_UNDEFINED_GLOBAL_ASSIGNMENT,
+				Token:   name,
+				Message: fmt.Sprintf("assignment to undefined variable '%s'", name.Lexeme),
+			})
+		}
+	}
+
+	for _, call := range r.globalCalls {
+		if global, ok := r.globals[call.Callee.(*ast.VariableExpr).Name.Lexeme]; ok {
+			if warning, ok := arityWarning(global, call); ok {
+				warnings = append(warnings, warning)
+			}
+		}
+	}
+
+	slices.SortStableFunc(warnings, func(a, b Warning) int {
+		if a.Token.Line != b.Token.Line {
+			return a.Token.Line - b.Token.Line
+		}
+
+		return a.Token.Column - b.Token.Column
+	})
+
+	return warnings
+}
+
 func (r *Resolver) VisitBlockStmt(stmt *ast.BlockStmt) (any, error) {
 	r.beginScope()
 	defer r.endScope()
@@ -60,7 +223,7 @@ func (r *Resolver) VisitClassStmt(stmt *ast.ClassStmt) (any, error) {
 	r.currClass = CLASS_TYPE_CLASS
 	defer func() { r.currClass = enclosingClass }()
 
-	r.declare(stmt.Name)
+	r.declare(stmt.Name, VARIABLE_KIND_CLASS, stmt)
 	r.define(stmt.Name)
 
 	if stmt.SuperClass != nil {
@@ -72,13 +235,13 @@ func (r *Resolver) VisitClassStmt(stmt *ast.ClassStmt) (any, error) {
 		r.resolveExpr(stmt.SuperClass)
 
 		r.beginScope()
-		r.scopes[len(r.scopes)-1]["super"] = true
+		r.scopes[len(r.scopes)-1]["super"] = &variable{kind: VARIABLE_KIND_IMPLICIT, isDefined: true}
 		defer r.endScope()
 	}
 
 	r.beginScope()
 	defer r.endScope()
-	r.scopes[len(r.scopes)-1]["this"] = true
+	r.scopes[len(r.scopes)-1]["this"] = &variable{kind: VARIABLE_KIND_IMPLICIT, isDefined: true}
 
 	for _, method := range stmt.Methods {
 		declaration := FUNCTION_TYPE_METHOD
@@ -98,7 +261,7 @@ func (r *Resolver) VisitExpressionStmt(stmt *ast.ExpressionStmt) (any, error) {
 }
 
 func (r *Resolver) VisitVarStmt(statement *ast.VarStmt) (any, error) {
-	r.declare(statement.Name)
+	r.declare(statement.Name, VARIABLE_KIND_VARIABLE, nil)
 	if statement.Initializer != nil {
 		r.resolveExpr(statement.Initializer)
 	}
@@ -116,20 +279,26 @@ func (r *Resolver) VisitWhileStmt(stmt *ast.WhileStmt) (any, error) {
 func (r *Resolver) VisitVariableExpr(expr *ast.VariableExpr) (any, error) {
 	if len(r.scopes) > 0 {
 		scope := r.scopes[len(r.scopes)-1]
-		isDefined, exists := scope[expr.Name.Lexeme]
-		if exists && !isDefined {
+		variable, exists := scope[expr.Name.Lexeme]
+		if exists && !variable.isDefined {
 			r.reportError(expr.Name, "can't read local variable in its own initializer")
 			return nil, nil
 		}
 	}
 
-	r.resolveLocal(expr, expr.Name)
+	variable := r.resolveLocal(expr, expr.Name)
+	if variable != nil {
+		variable.isUsed = true
+	} else {
+		r.readGlobals[expr.Name.Lexeme] = true
+	}
+	r.addReference(expr.Name, variable)
 
 	return nil, nil
 }
 
 func (r *Resolver) VisitFunctionStmt(statement *ast.FunctionStmt) (any, error) {
-	r.declare(statement.Name)
+	r.declare(statement.Name, VARIABLE_KIND_FUNCTION, statement)
 	r.define(statement.Name)
 
 	r.resolveFunction(statement, FUNCTION_TYPE_FUNCTION)
@@ -170,7 +339,11 @@ func (r *Resolver) VisitReturnStmt(stmt *ast.ReturnStmt) (any, error) {
 
 func (r *Resolver) VisitAssignmentExpr(expr *ast.AssignmentExpr) (any, error) {
 	r.resolveExpr(expr.Value)
-	r.resolveLocal(expr, expr.Name)
+	variable := r.resolveLocal(expr, expr.Name)
+	if variable == nil {
+		r.globalAssignments = append(r.globalAssignments, expr.Name)
+	}
+	r.addReference(expr.Name, variable)
 
 	return nil, nil
 }
@@ -184,6 +357,16 @@ func (r *Resolver) VisitBinaryExpr(expr *ast.BinaryExpr) (any, error) {
 func (r *Resolver) VisitCallExpr(expr *ast.CallExpr) (any, error) {
 	r.resolveExpr(expr.Callee)
 
+	if callee, ok := expr.Callee.(*ast.VariableExpr); ok {
+		if variable := r.findLocal(callee.Name); variable != nil {
+			if warning, ok := arityWarning(variable, expr); ok {
+				r.warnings = append(r.warnings, warning)
+			}
+		} else {
+			r.globalCalls = append(r.globalCalls, expr)
+		}
+	}
+
 	for _, arg := range expr.Arguments {
 		r.resolveExpr(arg)
 	}
@@ -245,10 +428,16 @@ func (r *Resolver) VisitUnaryExpr(expr *ast.UnaryExpr) (any, error) {
 }
 
 func (r *Resolver) beginScope() {
-	r.scopes = append(r.scopes, map[string]bool{})
+	r.scopes = append(r.scopes, map[string]*variable{})
 }
 
 func (r *Resolver) endScope() {
+	for _, variable := range r.scopes[len(r.scopes)-1] {
+		if !variable.isUsed && variable.kind != VARIABLE_KIND_IMPLICIT && !strings.HasPrefix(variable.name.Lexeme, "_") {
+			r.warnings = append(r.warnings, unusedWarning(variable))
+		}
+	}
+
 	r.scopes = r.scopes[:len(r.scopes)-1]
 }
 
@@ -259,7 +448,17 @@ func (r *Resolver) resolveBlock(statements []ast.Stmt) {
 }
 
 func (r *Resolver) resolveStmt(statement ast.Stmt) {
+	// only restored when the statement returns, so the innermost one is kept when an assertion fails
+	enclosing := r.statement
+	r.statement = statement
 	statement.Accept(r)
+	r.statement = enclosing
+}
+
+// where the innermost statement being resolved is, a line of 0 when that isn't known
+func (r *Resolver) position() (int, int) {
+	tok, _ := ast.StmtToken(r.statement)
+	return tok.Line, tok.Column
 }
 
 func (r *Resolver) resolveExpr(expr ast.Expr) {
@@ -275,15 +474,24 @@ func (r *Resolver) resolveFunction(funStmt *ast.FunctionStmt, funType functionTy
 	defer r.endScope()
 
 	for _, param := range funStmt.Parameters {
-		r.declare(param)
+		r.declare(param, VARIABLE_KIND_PARAMETER, nil)
 		r.define(param)
 	}
 
 	r.resolveBlock(funStmt.Body)
 }
 
-func (r *Resolver) declare(name token.Token) {
+// declaration is the function or class declared by the variable, and nil for other kinds of variables
+func (r *Resolver) declare(name token.Token, kind variableKind, declaration ast.Stmt) {
+	declared := &variable{
+		name:        name,
+		kind:        kind,
+		declaration: declaration,
+	}
+
 	if len(r.scopes) == 0 {
+		r.globals[name.Lexeme] = declared
+		r.declaredGlobals = append(r.declaredGlobals, declared)
 		return
 	}
 
@@ -292,7 +500,16 @@ func (r *Resolver) declare(name token.Token) {
 	if alreadyDefined {
 		r.reportError(name, "there is already a variable with that name in this scope")
 	}
-	scope[name.Lexeme] = false
+
+	if shadowed := r.findShadowed(name); shadowed != nil {
+		r.warnings = append(r.warnings, Warning{
+			Rule:    RULE_SHADOWED_NAME,
+			Token:   name,
+			Message: fmt.Sprintf("'%s' shadows a variable declared on line %d", name.Lexeme, shadowed.name.Line),
+		})
+	}
+
+	scope[name.Lexeme] = declared
 }
 
 func (r *Resolver) define(name token.Token) {
@@ -301,18 +518,112 @@ func (r *Resolver) define(name token.Token) {
 	}
 
 	scope := r.scopes[len(r.scopes)-1]
-	scope[name.Lexeme] = true
+	scope[name.Lexeme].isDefined = true
 }
 
-func (r *Resolver) resolveLocal(expr ast.Expr, name token.Token) {
+// returns the variable that expr refers to, or nil if it's a global
+func (r *Resolver) resolveLocal(expr ast.Expr, name token.Token) *variable {
 	for i := len(r.scopes) - 1; i >= 0; i-- {
 		scope := r.scopes[i]
-		_, hasName := scope[name.Lexeme]
+		variable, hasName := scope[name.Lexeme]
 		if hasName {
 			r.interpreter.Resolve(expr, len(r.scopes)-1-i)
-			return
+			return variable
+		}
+	}
+
+	r.interpreter.ResolveGlobal(expr)
+	return nil
+}
+
+// declared is nil for globals
+func (r *Resolver) addReference(use token.Token, declared *variable) {
+	if declared == nil {
+		r.globalReferences = append(r.globalReferences, use)
+		return
+	}
+
+	if declared.kind != VARIABLE_KIND_IMPLICIT {
+		r.references = append(r.references, Reference{Use: use, Declaration: declared.name})
+	}
+}
+
+func (r *Resolver) findLocal(name token.Token) *variable {
+	for i := len(r.scopes) - 1; i >= 0; i-- {
+		if variable, ok := r.scopes[i][name.Lexeme]; ok {
+			return variable
+		}
+	}
+
+	return nil
+}
+
+// returns the variable in an enclosing scope that a new variable in the innermost scope would shadow
+func (r *Resolver) findShadowed(name token.Token) *variable {
+	for i := len(r.scopes) - 2; i >= 0; i-- {
+		if variable, ok := r.scopes[i][name.Lexeme]; ok && variable.kind != VARIABLE_KIND_IMPLICIT {
+			return variable
+		}
+	}
+
+	return r.globals[name.Lexeme]
+}
+
+func unusedWarning(unused *variable) Warning {
+	switch unused.kind {
+	case VARIABLE_KIND_PARAMETER:
+		return Warning{
+			Rule:    RULE_UNUSED_PARAMETER,
+			Token:   unused.name,
+			Message: fmt.Sprintf("parameter '%s' is never used", unused.name.Lexeme),
+		}
+	case VARIABLE_KIND_FUNCTION:
+		return Warning{
+			Rule:    RULE_UNUSED_FUNCTION,
+			Token:   unused.name,
+			Message: fmt.Sprintf("function '%s' is never used", unused.name.Lexeme),
+		}
+	}
+
+	return Warning{
+		Rule:    RULE_UNUSED_VARIABLE,
+		Token:   unused.name,
+		Message: fmt.Sprintf("'%s' is never used", unused.name.Lexeme),
+	}
+}
+
+// checks calls to a variable declaring a function or class against the number of parameters they declare
+func arityWarning(callee *variable, call *ast.CallExpr) (Warning, bool) {
+	arity := 0
+	switch declaration := callee.declaration.(type) {
+	case *ast.FunctionStmt:
+		arity = len(declaration.Parameters)
+	case *ast.ClassStmt:
+		initializerFound := false
+		for _, method := range declaration.Methods {
+			if method.Name.Lexeme == "init" {
+				arity = len(method.Parameters)
+				initializerFound = true
+			}
 		}
+
+		// an inherited initializer can't be known without evaluating the superclass
+		if !initializerFound && declaration.SuperClass != nil {
+			return Warning{}, false
+		}
+	default:
+		return Warning{}, false
+	}
+
+	if arity == len(call.Arguments) {
+		return Warning{}, false
 	}
+
+	return Warning{
+		Rule:    RULE_WRONG_ARITY,
+		Token:   call.Paren,
+		Message: fmt.Sprintf("'%s' expects %d arguments but is called with %d", callee.name.Lexeme, arity, len(call.Arguments)),
+	}, true
 }
 
 func (r *Resolver) reportError(tok token.Token, msg any) {
@@ -320,11 +631,13 @@ func (r *Resolver) reportError(tok token.Token, msg any) {
 
 	if tok.Kind == token.EOF {
 		r.report(errors.NewBuildtimeError(tok.Line, tok.Column, " at end", msg))
+		return
 	}
 
 	r.report(errors.NewBuildtimeError(tok.Line, tok.Column, " at '"+tok.Lexeme+"'", msg))
 }
 
 func (r *Resolver) report(err error) {
-	fmt.Fprintln(os.Stderr, err)
+	r.errs = append(r.errs, err)
+	fmt.Fprintln(r.errOut, err)
 }
@@ -10,8 +10,10 @@ import (
 )
 
 type Scanner struct {
-	source          string
-	tokens          []token.Token
+	source string
+	tokens []token.Token
+	// comments are kept separately from the tokens, because they are ignored by the parser
+	comments        []token.Token
 	start, current  int
 	line, lineStart int
 }
@@ -23,8 +25,15 @@ func NewScanner(source string) Scanner {
 	}
 }
 
-func (s *Scanner) ScanTokens() ([]token.Token, []error) {
-	errs := []error{}
+// ScanTokens returns the tokens of the source, which always end with EOF, and the errors found while scanning it.
+// A failed internal assertion is returned as an errors.InternalError, and the tokens scanned before it are kept
+func (s *Scanner) ScanTokens() (tokens []token.Token, errs []error) {
+	defer errors.RecoverAssertion(s.position, func(err *errors.InternalError) {
+		errs = append(errs, err)
+		tokens = append(s.tokens, s.eof())
+	})
+
+	errs = []error{}
 	for !s.isAtEnd() {
 		s.start = s.current
 		if err := s.scanToken(); err != nil {
@@ -32,15 +41,29 @@ func (s *Scanner) ScanTokens() ([]token.Token, []error) {
 		}
 	}
 
-	s.tokens = append(s.tokens, token.Token{
+	s.tokens = append(s.tokens, s.eof())
+	return s.tokens, errs
+}
+
+func (s *Scanner) eof() token.Token {
+	return token.Token{
 		Kind:    token.EOF,
 		Lexeme:  "",
 		Literal: nil,
 		Line:    s.line,
 		Column:  s.currentColumn() + 1,
-	})
+	}
+}
 
-	return s.tokens, errs
+// where the scanner is, for internal errors
+func (s *Scanner) position() (int, int) {
+	return s.line, s.currentColumn()
+}
+
+// Comments returns the comments found by ScanTokens, in the order they appear in the source.
+// Unlike other tokens, the column of a comment is the column it starts at
+func (s *Scanner) Comments() []token.Token {
+	return s.comments
 }
 
 func (s *Scanner) scanToken() error {
@@ -79,7 +102,7 @@ func (s *Scanner) scanToken() error {
 			s.dropLine()
 		} else if s.match('*') {
 			if err := s.ignoreMultilineComment(); err != nil {
-				return nil
+				return err
 			}
 		} else {
 			s.addToken(token.SLASH)
@@ -234,12 +257,16 @@ func (s *Scanner) match(expected rune) bool {
 }
 
 func (s *Scanner) dropLine() {
+	line, column := s.line, s.currentColumn()-1
 	for s.peek() != '\n' && !s.isAtEnd() {
 		s.advance()
 	}
+
+	s.addComment(line, column)
 }
 
 func (s *Scanner) ignoreMultilineComment() error {
+	line, column := s.line, s.currentColumn()-1
 	for !(s.peek() == '*' && s.peekNext() == '/') && !s.isAtEnd() {
 		if s.peek() == '\n' {
 			s.line++
@@ -257,9 +284,20 @@ func (s *Scanner) ignoreMultilineComment() error {
 	s.advance() // consume *
 	s.advance() // consume /
 
+	s.addComment(line, column)
+
 	return nil
 }
 
+func (s *Scanner) addComment(line, column int) {
+	s.comments = append(s.comments, token.Token{
+		Kind:   token.COMMENT,
+		Lexeme: s.source[s.start:s.current],
+		Line:   line,
+		Column: column,
+	})
+}
+
 func (s *Scanner) error(msg any) error {
 	return errors.NewBuildtimeError(s.line, s.currentColumn(), "", msg)
 
@@ -0,0 +1,238 @@
+package testrunner
+
+import (
+	"encoding/xml"
+	goerrors "errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/Drumstickz64/golox/interpreting"
+)
+
+type Format int
+
+const (
+	// one line per test, followed by the reason of failures
+	FORMAT_TEXT Format = iota
+	// the Test Anything Protocol, version 13
+	FORMAT_TAP
+	// JUnit XML, as read by most CI servers
+	FORMAT_JUNIT
+)
+
+// ParseFormat returns the format called name, which is either "text", "tap" or "junit"
+func ParseFormat(name string) (Format, bool) {
+	switch name {
+	case "text":
+		return FORMAT_TEXT, true
+	case "tap":
+		return FORMAT_TAP, true
+	case "junit":
+		return FORMAT_JUNIT, true
+	}
+
+	return 0, false
+}
+
+// Count returns how many of results passed, failed and errored
+func Count(results []Result) (passed, failed, errored int) {
+	for _, result := range results {
+		switch result.Status {
+		case STATUS_PASSED:
+			passed++
+		case STATUS_FAILED:
+			failed++
+		case STATUS_ERRORED:
+			errored++
+		}
+	}
+
+	return passed, failed, errored
+}
+
+// Write writes results in format
+func Write(w io.Writer, format Format, results []Result) error {
+	switch format {
+	case FORMAT_TAP:
+		return WriteTap(w, results)
+	case FORMAT_JUNIT:
+		return WriteJunit(w, results)
+	}
+
+	return WriteText(w, results)
+}
+
+func WriteText(w io.Writer, results []Result) error {
+	report := strings.Builder{}
+	for _, result := range results {
+		status := "ok"
+		switch result.Status {
+		case STATUS_FAILED:
+			status = "FAIL"
+		case STATUS_ERRORED:
+			status = "ERROR"
+		}
+
+		fmt.Fprintf(&report, "%-5s %s", status, result.File)
+		if result.Name != "" {
+			fmt.Fprintf(&report, ":%d %s (%v)", result.Line, result.Name, result.Duration)
+		}
+		report.WriteString("\n")
+
+		if result.Err != nil {
+			for _, line := range strings.Split(describe(result), "\n") {
+				fmt.Fprintf(&report, "      %s\n", line)
+			}
+		}
+	}
+
+	passed, failed, errored := Count(results)
+	fmt.Fprintf(&report, "\n%d passed, %d failed, %d errored\n", passed, failed, errored)
+
+	_, err := io.WriteString(w, report.String())
+	return err
+}
+
+func WriteTap(w io.Writer, results []Result) error {
+	report := strings.Builder{}
+	report.WriteString("TAP version 13\n")
+	fmt.Fprintf(&report, "1..%d\n", len(results))
+	for i, result := range results {
+		status := "ok"
+		if result.Status != STATUS_PASSED {
+			status = "not ok"
+		}
+
+		name := result.File
+		if result.Name != "" {
+			name = fmt.Sprintf("%s %s", result.File, result.Name)
+		}
+		fmt.Fprintf(&report, "%s %d - %s\n", status, i+1, name)
+
+		if result.Err != nil {
+			// details are a YAML block, using a literal block scalar keeps multiline messages intact
+			report.WriteString("  ---\n")
+			fmt.Fprintf(&report, "  severity: %s\n", result.Status)
+			fmt.Fprintf(&report, "  at: %s\n", location(result))
+			report.WriteString("  message: |\n")
+			for _, line := range strings.Split(describe(result), "\n") {
+				fmt.Fprintf(&report, "    %s\n", line)
+			}
+			report.WriteString("  ...\n")
+		}
+	}
+
+	_, err := io.WriteString(w, report.String())
+	return err
+}
+
+type junitSuites struct {
+	XMLName xml.Name     `xml:"testsuites"`
+	Suites  []junitSuite `xml:"testsuite"`
+}
+
+type junitSuite struct {
+	Name     string      `xml:"name,attr"`
+	Tests    int         `xml:"tests,attr"`
+	Failures int         `xml:"failures,attr"`
+	Errors   int         `xml:"errors,attr"`
+	Time     string      `xml:"time,attr"`
+	Cases    []junitCase `xml:"testcase"`
+	duration time.Duration
+}
+
+type junitCase struct {
+	Name      string        `xml:"name,attr"`
+	Classname string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitProblem `xml:"failure,omitempty"`
+	Error     *junitProblem `xml:"error,omitempty"`
+}
+
+type junitProblem struct {
+	Message string `xml:"message,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// WriteJunit writes results as JUnit XML, with a test suite for every file
+func WriteJunit(w io.Writer, results []Result) error {
+	suites := junitSuites{}
+	for _, result := range results {
+		if len(suites.Suites) == 0 || suites.Suites[len(suites.Suites)-1].Name != result.File {
+			suites.Suites = append(suites.Suites, junitSuite{Name: result.File})
+		}
+
+		suite := &suites.Suites[len(suites.Suites)-1]
+		suite.Tests++
+		suite.duration += result.Duration
+
+		testCase := junitCase{
+			Name:      result.Name,
+			Classname: result.File,
+			Time:      seconds(result.Duration),
+		}
+		if result.Name == "" {
+			testCase.Name = result.File
+		}
+
+		if result.Err != nil {
+			problem := &junitProblem{Message: firstLine(describe(result)), Text: describe(result)}
+			if result.Status == STATUS_FAILED {
+				testCase.Failure = problem
+				suite.Failures++
+			} else {
+				testCase.Error = problem
+				suite.Errors++
+			}
+		}
+
+		suite.Cases = append(suite.Cases, testCase)
+	}
+
+	for i := range suites.Suites {
+		suites.Suites[i].Time = seconds(suites.Suites[i].duration)
+	}
+
+	encoded, err := xml.MarshalIndent(suites, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(w, "%s%s\n", xml.Header, encoded)
+	return err
+}
+
+// where the result's problem is, the failed assertion for failures and the test function otherwise
+func location(result Result) string {
+	var assertionErr *interpreting.AssertionError
+	if goerrors.As(result.Err, &assertionErr) {
+		return fmt.Sprintf("%s:%d:%d", result.File, assertionErr.Token.Line, assertionErr.Token.Column)
+	}
+
+	if result.Name == "" {
+		return result.File
+	}
+
+	return fmt.Sprintf("%s:%d", result.File, result.Line)
+}
+
+// the error of a result, prefixed with where it happened
+func describe(result Result) string {
+	var assertionErr *interpreting.AssertionError
+	if goerrors.As(result.Err, &assertionErr) {
+		return fmt.Sprintf("%s: assertion failed: %s", location(result), assertionErr.Msg)
+	}
+
+	return result.Err.Error()
+}
+
+func firstLine(str string) string {
+	line, _, _ := strings.Cut(str, "\n")
+	return line
+}
+
+func seconds(duration time.Duration) string {
+	return fmt.Sprintf("%.6f", duration.Seconds())
+}
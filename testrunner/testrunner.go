@@ -0,0 +1,191 @@
+package testrunner
+
+import (
+	"context"
+	goerrors "errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Drumstickz64/golox/ast"
+	"github.com/Drumstickz64/golox/interpreting"
+	"github.com/Drumstickz64/golox/parsing"
+	"github.com/Drumstickz64/golox/resolving"
+	"github.com/Drumstickz64/golox/scanning"
+)
+
+// files ending with this suffix are test files
+const TEST_FILE_SUFFIX = "_test.lox"
+
+// top-level functions starting with this prefix are tests
+const TEST_FUNCTION_PREFIX = "test"
+
+type Status int
+
+const (
+	STATUS_PASSED Status = iota
+	// an assertion didn't hold
+	STATUS_FAILED
+	// the test couldn't run to completion for any other reason, like a runtime error
+	STATUS_ERRORED
+)
+
+func (s Status) String() string {
+	switch s {
+	case STATUS_PASSED:
+		return "passed"
+	case STATUS_FAILED:
+		return "failed"
+	}
+
+	return "errored"
+}
+
+type Result struct {
+	File string
+	// name of the test function, empty when the file itself couldn't be built
+	Name string
+	// line the test function is declared on
+	Line     int
+	Status   Status
+	Err      error
+	Duration time.Duration
+}
+
+// Discover returns the test files in paths, sorted. Directories are searched recursively,
+// files are included even if they don't end with TEST_FILE_SUFFIX
+func Discover(paths []string) ([]string, error) {
+	files := []string{}
+	for _, pth := range paths {
+		info, err := os.Stat(pth)
+		if err != nil {
+			return nil, err
+		}
+
+		if !info.IsDir() {
+			files = append(files, pth)
+			continue
+		}
+
+		err = filepath.WalkDir(pth, func(pth string, entry fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+
+			if !entry.IsDir() && strings.HasSuffix(pth, TEST_FILE_SUFFIX) {
+				files = append(files, pth)
+			}
+
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	slices.Sort(files)
+	return slices.Compact(files), nil
+}
+
+// RunFile runs every test in the file at pth. Each test gets its own interpreter, created by newInterpreter,
+// which runs the top-level code of the file before calling the test function.
+// timeout limits how long each test runs, including the top-level code, 0 for no limit
+func RunFile(pth string, newInterpreter func() *interpreting.Interpreter, timeout time.Duration) []Result {
+	source, err := os.ReadFile(pth)
+	if err != nil {
+		return []Result{{File: pth, Status: STATUS_ERRORED, Err: err}}
+	}
+
+	statements, errs := build(string(source), newInterpreter())
+	if len(errs) > 0 {
+		return []Result{{File: pth, Status: STATUS_ERRORED, Err: goerrors.Join(errs...)}}
+	}
+
+	results := []Result{}
+	for _, statement := range statements {
+		declaration, ok := statement.(*ast.FunctionStmt)
+		if !ok || !strings.HasPrefix(declaration.Name.Lexeme, TEST_FUNCTION_PREFIX) {
+			continue
+		}
+
+		start := time.Now()
+		err := runTest(statements, declaration, newInterpreter(), timeout)
+		result := Result{
+			File:     pth,
+			Name:     declaration.Name.Lexeme,
+			Line:     declaration.Name.Line,
+			Status:   STATUS_PASSED,
+			Err:      err,
+			Duration: time.Since(start),
+		}
+
+		var assertionErr *interpreting.AssertionError
+		if goerrors.As(err, &assertionErr) {
+			result.Status = STATUS_FAILED
+		} else if err != nil {
+			result.Status = STATUS_ERRORED
+		}
+
+		results = append(results, result)
+	}
+
+	return results
+}
+
+func runTest(statements []ast.Stmt, declaration *ast.FunctionStmt, interpreter *interpreting.Interpreter, timeout time.Duration) error {
+	interpreter.EnableAssertions()
+	if hadError := resolving.NewResolver(interpreter).Resolve(statements); hadError {
+		return goerrors.New("failed to resolve the test file")
+	}
+
+	ctx := context.Background()
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	if err := interpreter.InterpretContext(ctx, statements); err != nil {
+		return err
+	}
+
+	value, _ := interpreter.Globals().Value(declaration.Name.Lexeme)
+	test, ok := value.(interpreting.Callable)
+	if !ok || test.Arity() != 0 {
+		return goerrors.New("tests must be functions without parameters")
+	}
+
+	// the test is called like the script would call it, so internal errors are recovered from,
+	// and limits and observers apply to it
+	_, err := interpreter.EvaluateContext(ctx, &ast.CallExpr{
+		Callee: &ast.VariableExpr{Name: declaration.Name},
+		Paren:  declaration.Name,
+	})
+	return err
+}
+
+// scans, parses and resolves source, so build errors are reported once for the whole file
+func build(source string, interpreter *interpreting.Interpreter) ([]ast.Stmt, []error) {
+	scanner := scanning.NewScanner(source)
+	tokens, errs := scanner.ScanTokens()
+	if len(errs) > 0 {
+		return nil, errs
+	}
+
+	parser := parsing.NewParser(tokens)
+	statements, errs := parser.Parse()
+	if len(errs) > 0 {
+		return nil, errs
+	}
+
+	interpreter.EnableAssertions()
+	resolver := resolving.NewResolver(interpreter)
+	if hadError := resolver.Resolve(statements); hadError {
+		return nil, resolver.Errors()
+	}
+
+	return statements, nil
+}
@@ -76,6 +76,10 @@ const (
 	VAR
 	WHILE
 
+	// Trivia, kept by the scanner outside of the token list.
+
+	COMMENT
+
 	EOF
 )
 
@@ -91,6 +95,8 @@ func (k Kind) String() string {
 		return "class"
 	case COMMA:
 		return "comma"
+	case COMMENT:
+		return "comment"
 	case DOT:
 		return "dot"
 	case ELSE:
@@ -164,6 +170,17 @@ func (k Kind) String() string {
 	}
 }
 
+// ParseKind returns the kind whose String() is name
+func ParseKind(name string) (Kind, bool) {
+	for kind := LEFT_PAREN; kind <= EOF; kind++ {
+		if kind.String() == name {
+			return kind, true
+		}
+	}
+
+	return 0, false
+}
+
 type Token struct {
 	Kind         Kind
 	Lexeme       string
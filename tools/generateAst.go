@@ -13,6 +13,25 @@ import (
 	"github.com/Drumstickz64/golox/errors"
 )
 
+// node fields that may be null in the JSON representation, every other node has to be present
+var optionalFields = map[string]bool{
+	"Return.Value":     true,
+	"Var.Initializer":  true,
+	"If.ElseBranch":    true,
+	"Class.SuperClass": true,
+}
+
+// the token kinds the operator of each kind of node can have
+var operatorKinds = map[string][]string{
+	"Binary.Operator": {
+		"token.PLUS", "token.MINUS", "token.STAR", "token.SLASH",
+		"token.GREATER", "token.GREATER_EQUAL", "token.LESS", "token.LESS_EQUAL",
+		"token.EQUAL_EQUAL", "token.BANG_EQUAL",
+	},
+	"Logical.Operator": {"token.AND", "token.OR"},
+	"Unary.Operator":   {"token.MINUS", "token.BANG"},
+}
+
 func main() {
 	if len(os.Args) != 2 {
 		fmt.Println("Usage: generateAst <output_directory>")
@@ -20,7 +39,7 @@ func main() {
 	}
 
 	outputDir := os.Args[1]
-	err := defineAst(outputDir, "Expr", []string{
+	exprKinds := []string{
 		"Binary     : Left Expr, Operator token.Token, Right Expr",
 		"Logical    : Left Expr, Operator token.Token, Right Expr",
 		"Grouping   : Expression Expr",
@@ -33,7 +52,8 @@ func main() {
 		"This       : Keyword token.Token",
 		"Variable   : Name token.Token",
 		"Assignment : Name token.Token, Value Expr",
-	}, []string{
+	}
+	err := defineAst(outputDir, "Expr", exprKinds, []string{
 		"github.com/Drumstickz64/golox/token",
 	})
 
@@ -41,7 +61,7 @@ func main() {
 		errors.LogCliError("error while generating expr AST: "+err.Error(), 65)
 	}
 
-	err = defineAst(outputDir, "Stmt", []string{
+	stmtKinds := []string{
 		"Block      : Statements []Stmt",
 		"Class      : Name token.Token, SuperClass *VariableExpr, Methods []*FunctionStmt",
 		"Expression : Expression Expr",
@@ -51,13 +71,28 @@ func main() {
 		"Return     : Keyword token.Token, Value Expr",
 		"Var        : Name token.Token, Initializer Expr",
 		"Function   : Name token.Token, Parameters []token.Token, Body []Stmt",
-	}, []string{
+	}
+	err = defineAst(outputDir, "Stmt", stmtKinds, []string{
 		"github.com/Drumstickz64/golox/token",
 	})
 
 	if err != nil {
 		errors.LogCliError("error while generating expr AST: "+err.Error(), 65)
 	}
+
+	if err := defineJson(outputDir, "Expr", exprKinds, []string{
+		"fmt",
+		"github.com/Drumstickz64/golox/token",
+	}); err != nil {
+		errors.LogCliError("error while generating expr JSON conversion: "+err.Error(), 65)
+	}
+
+	if err := defineJson(outputDir, "Stmt", stmtKinds, []string{
+		"fmt",
+		"github.com/Drumstickz64/golox/token",
+	}); err != nil {
+		errors.LogCliError("error while generating stmt JSON conversion: "+err.Error(), 65)
+	}
 }
 
 func defineAst(outputDir, baseName string, kinds []string, imports []string) error {
@@ -145,6 +180,166 @@ func defineImports(imports []string) string {
 	return content
 }
 
+// generates the conversion of every kind of node to and from the values produced by encoding/json.
+// The helpers used by the generated code live in ast/json.go
+func defineJson(outputDir, baseName string, kinds []string, imports []string) error {
+	packageName := strings.ToLower(baseName)
+	lowerBase := strings.ToLower(baseName[0:1]) + baseName[1:]
+	content := ""
+
+	content += "package ast\n"
+	content += "\n"
+
+	content += defineImports(imports)
+
+	content += "\n"
+
+	content += fmt.Sprintf("func %sToJson(node %s) any {\n", lowerBase, baseName)
+	content += "	switch node := node.(type) {\n"
+	for _, kind := range kinds {
+		kindName, fields := splitKind(kind)
+		itemName := kindName + baseName
+		content += fmt.Sprintf("	case *%s:\n", itemName)
+		// typed nil pointers, like a ClassStmt without a superclass, are not nil interfaces
+		content += "		if node == nil {\n"
+		content += "			return nil\n"
+		content += "		}\n"
+		content += "\n"
+		content += "		return map[string]any{\n"
+		content += fmt.Sprintf("			\"type\": \"%s\",\n", itemName)
+		for _, field := range fields {
+			fieldName, fieldType := splitField(field)
+			content += fmt.Sprintf("			\"%s\": %s,\n", jsonFieldName(fieldName), encodeField("node."+fieldName, fieldType))
+		}
+		content += "		}\n"
+	}
+	content += "	}\n"
+	content += "\n"
+	content += "	return nil\n"
+	content += "}\n"
+	content += "\n"
+
+	content += fmt.Sprintf("func %sFromJson(data any) (%s, error) {\n", lowerBase, baseName)
+	content += "	if data == nil {\n"
+	content += "		return nil, nil\n"
+	content += "	}\n"
+	content += "\n"
+	content += "	fields, kind, err := nodeFields(data)\n"
+	content += "	if err != nil {\n"
+	content += "		return nil, err\n"
+	content += "	}\n"
+	content += "\n"
+	content += "	switch kind {\n"
+	for _, kind := range kinds {
+		kindName, fields := splitKind(kind)
+		itemName := kindName + baseName
+		content += fmt.Sprintf("	case \"%s\":\n", itemName)
+		for _, field := range fields {
+			fieldName, fieldType := splitField(field)
+			data := fmt.Sprintf("fields[\"%s\"]", jsonFieldName(fieldName))
+			decoded := decodeField(data, fieldType, optionalFields[kindName+"."+fieldName])
+			if kinds, ok := operatorKinds[kindName+"."+fieldName]; ok {
+				decoded = fmt.Sprintf("operatorFromJson(%s, %s)", data, strings.Join(kinds, ", "))
+			}
+
+			content += fmt.Sprintf("		%s, err := %s\n", jsonFieldName(fieldName), decoded)
+			content += "		if err != nil {\n"
+			content += fmt.Sprintf("			return nil, fmt.Errorf(\"%s.%s: %%w\", err)\n", itemName, jsonFieldName(fieldName))
+			content += "		}\n"
+			content += "\n"
+		}
+		content += fmt.Sprintf("		return &%s{\n", itemName)
+		for _, field := range fields {
+			fieldName, _ := splitField(field)
+			content += fmt.Sprintf("			%s: %s,\n", fieldName, jsonFieldName(fieldName))
+		}
+		content += "		}, nil\n"
+	}
+	content += "	}\n"
+	content += "\n"
+	content += fmt.Sprintf("	return nil, fmt.Errorf(\"unknown %s type '%%s'\", kind)\n", packageName)
+	content += "}\n"
+
+	pth := path.Join(outputDir, packageName+"json.go")
+	if err := os.WriteFile(pth, []byte(content), 0777); err != nil {
+		return err
+	}
+
+	return formatFile(pth)
+}
+
+// returns the expression that converts the value of a field to JSON
+func encodeField(value, fieldType string) string {
+	if elemType, isSlice := strings.CutPrefix(fieldType, "[]"); isSlice {
+		return fmt.Sprintf("sliceToJson(%s, func(item %s) any { return %s })", value, elemType, encodeField("item", elemType))
+	}
+
+	switch fieldType {
+	case "Expr":
+		return fmt.Sprintf("exprToJson(%s)", value)
+	case "Stmt":
+		return fmt.Sprintf("stmtToJson(%s)", value)
+	case "token.Token":
+		return fmt.Sprintf("tokenToJson(%s)", value)
+	case "any":
+		return value
+	}
+
+	if strings.HasSuffix(fieldType, "Expr") {
+		return fmt.Sprintf("exprToJson(%s)", value)
+	}
+
+	return fmt.Sprintf("stmtToJson(%s)", value)
+}
+
+// returns the expression that converts JSON to the value of a field and an error.
+// Nodes that aren't optional can't be null, and neither can the items of a slice
+func decodeField(data, fieldType string, optional bool) string {
+	if elemType, isSlice := strings.CutPrefix(fieldType, "[]"); isSlice {
+		return fmt.Sprintf("sliceFromJson(%s, func(item any) (%s, error) { return %s })", data, elemType, decodeField("item", elemType, false))
+	}
+
+	decoded := ""
+	switch fieldType {
+	case "token.Token":
+		return fmt.Sprintf("tokenFromJson(%s)", data)
+	case "any":
+		return fmt.Sprintf("literalFromJson(%s)", data)
+	case "Expr":
+		decoded = fmt.Sprintf("exprFromJson(%s)", data)
+	case "Stmt":
+		decoded = fmt.Sprintf("stmtFromJson(%s)", data)
+	default:
+		// pointers to a specific kind of node
+		if strings.HasSuffix(fieldType, "Expr") {
+			decoded = fmt.Sprintf("castNode[%s](exprFromJson(%s))", fieldType, data)
+		} else {
+			decoded = fmt.Sprintf("castNode[%s](stmtFromJson(%s))", fieldType, data)
+		}
+	}
+
+	if optional {
+		return decoded
+	}
+
+	return fmt.Sprintf("requiredNode(%s)", decoded)
+}
+
+func splitKind(kind string) (string, []string) {
+	kindName := strings.TrimSpace(strings.Split(kind, ":")[0])
+	fields := strings.TrimSpace(strings.Split(kind, ":")[1])
+	return kindName, strings.Split(fields, ", ")
+}
+
+func splitField(field string) (string, string) {
+	parts := strings.SplitN(field, " ", 2)
+	return parts[0], parts[1]
+}
+
+func jsonFieldName(fieldName string) string {
+	return strings.ToLower(fieldName[0:1]) + fieldName[1:]
+}
+
 func formatFile(pth string) error {
 	return exec.Command("go", "fmt", pth).Run()
 }
@@ -0,0 +1,184 @@
+package tracing
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+
+	"github.com/Drumstickz64/golox/ast"
+	"github.com/Drumstickz64/golox/interpreting"
+	"github.com/Drumstickz64/golox/parsing"
+	"github.com/Drumstickz64/golox/token"
+)
+
+type Format int
+
+const (
+	// one event per line, indented by call depth
+	FORMAT_TEXT Format = iota
+	// one JSON object per line
+	FORMAT_JSON
+)
+
+// ParseFormat returns the format called name, which is either "text" or "json"
+func ParseFormat(name string) (Format, bool) {
+	switch name {
+	case "text":
+		return FORMAT_TEXT, true
+	case "json":
+		return FORMAT_JSON, true
+	}
+
+	return 0, false
+}
+
+type event struct {
+	Event  string `json:"event"`
+	Line   int    `json:"line"`
+	Depth  int    `json:"depth"`
+	Kind   string `json:"kind,omitempty"`
+	Source string `json:"source,omitempty"`
+	Callee string `json:"callee,omitempty"`
+	// pointers, so an empty list of arguments is still written
+	Arguments *[]string `json:"arguments,omitempty"`
+	Name      string    `json:"name,omitempty"`
+	Value     *string   `json:"value,omitempty"`
+	Error     string    `json:"error,omitempty"`
+}
+
+// Tracer is an interpreter observer that logs every executed statement, call and assignment
+type Tracer struct {
+	out      io.Writer
+	format   Format
+	lines    []string
+	spans    map[ast.Stmt]ast.Span
+	forLoops map[ast.Stmt]*ast.ForLoop
+	// the number of calls that haven't returned yet
+	depth int
+	// the first error hit while writing, after which nothing else is written
+	err error
+}
+
+// source and parser are used to show where statements come from, the zero parser can be used when they're unknown
+func NewTracer(out io.Writer, format Format, source string, parser parsing.Parser) *Tracer {
+	return &Tracer{
+		out:      out,
+		format:   format,
+		lines:    strings.Split(source, "\n"),
+		spans:    parser.Spans,
+		forLoops: parser.ForLoops,
+	}
+}
+
+// Err returns the first error hit while writing the trace
+func (t *Tracer) Err() error {
+	return t.err
+}
+
+func (t *Tracer) OnStatement(stmt ast.Stmt) {
+	span, ok := t.spans[stmt]
+	if !ok {
+		return
+	}
+
+	line := span.Start.Line
+	source := ""
+	if line > 0 && line <= len(t.lines) {
+		source = strings.TrimSpace(t.lines[line-1])
+	}
+
+	kind := stmtKind(stmt)
+	if _, ok := t.forLoops[stmt]; ok {
+		kind = "for"
+	}
+
+	t.write(event{
+		Event:  "statement",
+		Line:   line,
+		Kind:   kind,
+		Source: source,
+	})
+}
+
+func (t *Tracer) OnCall(callee interpreting.Callable, paren token.Token, arguments []any) {
+	stringified := make([]string, 0, len(arguments))
+	for _, argument := range arguments {
+		stringified = append(stringified, interpreting.Stringify(argument))
+	}
+
+	t.write(event{
+		Event:     "call",
+		Line:      paren.Line,
+		Callee:    interpreting.Stringify(callee),
+		Arguments: &stringified,
+	})
+	t.depth++
+}
+
+func (t *Tracer) OnReturn(callee interpreting.Callable, paren token.Token, result any, err error) {
+	t.depth--
+	returned := event{
+		Event:  "return",
+		Line:   paren.Line,
+		Callee: interpreting.Stringify(callee),
+	}
+
+	if err != nil {
+		returned.Error = err.Error()
+	} else {
+		value := interpreting.Stringify(result)
+		returned.Value = &value
+	}
+
+	t.write(returned)
+}
+
+func (t *Tracer) OnAssignment(name token.Token, value any) {
+	stringified := interpreting.Stringify(value)
+	t.write(event{
+		Event: "assignment",
+		Line:  name.Line,
+		Name:  name.Lexeme,
+		Value: &stringified,
+	})
+}
+
+func (t *Tracer) write(e event) {
+	if t.err != nil {
+		return
+	}
+
+	e.Depth = t.depth
+	if t.format == FORMAT_JSON {
+		encoder := json.NewEncoder(t.out)
+		encoder.SetEscapeHTML(false)
+		t.err = encoder.Encode(e)
+		return
+	}
+
+	indentation := strings.Repeat("  ", t.depth)
+	switch e.Event {
+	case "statement":
+		_, t.err = fmt.Fprintf(t.out, "%4d %s%s: %s\n", e.Line, indentation, e.Kind, e.Source)
+	case "call":
+		_, t.err = fmt.Fprintf(t.out, "%4d %scall %s(%s)\n", e.Line, indentation, e.Callee, strings.Join(*e.Arguments, ", "))
+	case "return":
+		if e.Value == nil {
+			// runtime errors span several lines, which would break the one event per line format
+			message := strings.ReplaceAll(e.Error, "\n", " ")
+			_, t.err = fmt.Fprintf(t.out, "%4d %sreturn %s failed: %s\n", e.Line, indentation, e.Callee, message)
+		} else {
+			_, t.err = fmt.Fprintf(t.out, "%4d %sreturn %s -> %s\n", e.Line, indentation, e.Callee, *e.Value)
+		}
+	case "assignment":
+		_, t.err = fmt.Fprintf(t.out, "%4d %s%s = %s\n", e.Line, indentation, e.Name, *e.Value)
+	}
+}
+
+// the name of the statement's type, like "var" for *ast.VarStmt
+func stmtKind(stmt ast.Stmt) string {
+	name := reflect.TypeOf(stmt).Elem().Name()
+	return strings.ToLower(strings.TrimSuffix(name, "Stmt"))
+}